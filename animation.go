@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unsafe"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/ncruces/zenity"
+	"github.com/phuslu/log"
+)
+
+// Frames per second of the exported animations
+const animationFPS = 20
+
+// maxAnimationFrames bounds the size of the animations, the replay revealing more nodes per frame
+// and the camera moving faster when needed.
+const maxAnimationFrames = 600
+
+type animationSource int
+
+const (
+	// replayAnimation reveals the nodes as the replay does
+	replayAnimation animationSource = iota
+	// bookmarksAnimation moves the camera from a bookmark to the next
+	bookmarksAnimation
+)
+
+var animationSources = []string{"Replay of the exploration", "Camera path between bookmarks"}
+
+var animationSizes = []struct{ width, height int32 }{{640, 360}, {1280, 720}, {1920, 1080}}
+
+// animationExport is an export of an animation waiting for the next frame, as viewExport.
+type animationExport struct {
+	filename      string
+	source        animationSource
+	width, height int32
+}
+
+// askAnimationExport asks what to animate, the resolution, and the file of the animation: a gif, or
+// a png per frame.
+func (e *treeEngine) askAnimationExport() {
+	choice, err := zenity.List("Animation to export", animationSources,
+		zenity.Title("Export Animation"),
+		zenity.DefaultItems(animationSources[0]))
+	if err != nil {
+		log.Info().Err(err).Msg("export animation")
+		return
+	}
+	source := animationSource(max(slices.Index(animationSources, choice), 0))
+
+	sizes := make([]string, 0, len(animationSizes))
+	for _, s := range animationSizes {
+		sizes = append(sizes, fmt.Sprintf("%dx%d", s.width, s.height))
+	}
+	choice, err = zenity.List("Resolution of the frames", sizes,
+		zenity.Title("Export Animation"),
+		zenity.DefaultItems(sizes[0]))
+	if err != nil {
+		log.Info().Err(err).Msg("export animation")
+		return
+	}
+	size := animationSizes[max(slices.Index(sizes, choice), 0)]
+
+	file, err := zenity.SelectFileSave(
+		zenity.Title("Export Animation"),
+		zenity.Filename("replay.gif"),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilter{Name: "GIF animation", Patterns: []string{"*.gif"}, CaseFold: true},
+		zenity.FileFilter{Name: "PNG sequence", Patterns: []string{"*.png"}, CaseFold: true})
+	if err != nil {
+		log.Info().Err(err).Msg("export animation")
+		return
+	}
+
+	e.pendingAnimation = &animationExport{filename: file, source: source, width: size.width, height: size.height}
+}
+
+// frameWriter writes the frames of an animation, as soon as they are added.
+type frameWriter interface {
+	Add(frame *image.RGBA) error
+	Close() error
+	// Remove deletes what was written, when the export is canceled
+	Remove() error
+}
+
+// newFrameWriter writes a png per frame when the file is a png, numbered after its name, and a gif
+// otherwise.
+func newFrameWriter(filename string) (frameWriter, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".png") {
+		return &pngSequence{prefix: strings.TrimSuffix(filename, filepath.Ext(filename))}, nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &gifWriter{file: f, out: bufio.NewWriter(f), indices: make(map[uint32]uint8)}, nil
+}
+
+type pngSequence struct {
+	prefix string
+	files  []string
+}
+
+func (p *pngSequence) Add(frame *image.RGBA) error {
+	file := fmt.Sprintf("%s-%04d.png", p.prefix, len(p.files)+1)
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	p.files = append(p.files, file)
+	if err := png.Encode(f, frame); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (p *pngSequence) Close() error {
+	return nil
+}
+
+func (p *pngSequence) Remove() error {
+	for _, f := range p.files {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gifWriter writes every frame as soon as it is added, reduced to the colors of the Plan 9 palette.
+// The standard encoder only writes whole animations: each frame is encoded as a gif of its own, the
+// blocks of its image being appended to the file.
+type gifWriter struct {
+	file   *os.File
+	out    *bufio.Writer
+	frames int
+	// Index in the palette of the colors seen, the nearest color being slow to find
+	indices map[uint32]uint8
+	encoded bytes.Buffer
+}
+
+// gifLoopForever is the application extension repeating the animation.
+var gifLoopForever = []byte{0x21, 0xff, 0x0b, 'N', 'E', 'T', 'S', 'C', 'A', 'P', 'E', '2', '.', '0', 0x03, 0x01, 0x00, 0x00, 0x00}
+
+const gifTrailer = 0x3b
+
+func (g *gifWriter) Add(frame *image.RGBA) error {
+	bounds := frame.Bounds()
+	paletted := image.NewPaletted(bounds, palette.Plan9)
+	for y := 0; y < bounds.Dy(); y++ {
+		row := frame.Pix[y*frame.Stride : y*frame.Stride+4*bounds.Dx()]
+		for x := 0; x < bounds.Dx(); x++ {
+			r, gr, b, a := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+			key := uint32(r)<<24 | uint32(gr)<<16 | uint32(b)<<8 | uint32(a)
+			i, ok := g.indices[key]
+			if !ok {
+				i = uint8(color.Palette(palette.Plan9).Index(color.RGBA{R: r, G: gr, B: b, A: a}))
+				g.indices[key] = i
+			}
+			paletted.Pix[y*paletted.Stride+x] = i
+		}
+	}
+
+	g.encoded.Reset()
+	animation := &gif.GIF{Image: []*image.Paletted{paletted}, Delay: []int{100 / animationFPS}}
+	if err := gif.EncodeAll(&g.encoded, animation); err != nil {
+		return err
+	}
+	data := g.encoded.Bytes()
+	// header, logical screen descriptor and global color table
+	header := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		header += 3 << ((flags & 0x07) + 1)
+	}
+	if g.frames == 0 {
+		if _, err := g.out.Write(data[:header]); err != nil {
+			return err
+		}
+		if _, err := g.out.Write(gifLoopForever); err != nil {
+			return err
+		}
+	}
+	g.frames++
+	_, err := g.out.Write(data[header : len(data)-1])
+	return err
+}
+
+func (g *gifWriter) Close() error {
+	if g.frames > 0 {
+		if err := g.out.WriteByte(gifTrailer); err != nil {
+			g.file.Close()
+			return err
+		}
+	}
+	if err := g.out.Flush(); err != nil {
+		g.file.Close()
+		return err
+	}
+	return g.file.Close()
+}
+
+func (g *gifWriter) Remove() error {
+	return os.Remove(g.file.Name())
+}
+
+// frameEncoder writes the frames in the background, the rendering staying on the main thread. Only a
+// few frames wait to be written, long animations are not kept in memory.
+type frameEncoder struct {
+	writer frameWriter
+	frames chan *image.RGBA
+	done   chan error
+}
+
+func newFrameEncoder(writer frameWriter) *frameEncoder {
+	enc := &frameEncoder{writer: writer, frames: make(chan *image.RGBA, 4), done: make(chan error, 1)}
+	go func() {
+		var err error
+		for frame := range enc.frames {
+			// the frames are still received after an error, so that Add does not block
+			if err == nil {
+				err = writer.Add(frame)
+			}
+		}
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		enc.done <- err
+	}()
+	return enc
+}
+
+// Add copies the pixels of the frame, which can be unloaded once added.
+func (enc *frameEncoder) Add(frame *rl.Image) {
+	if frame.Format != rl.UncompressedR8g8b8a8 {
+		rl.ImageFormat(frame, rl.UncompressedR8g8b8a8)
+	}
+	width, height := int(frame.Width), int(frame.Height)
+	pixels := unsafe.Slice((*byte)(frame.Data), width*height*4)
+	enc.frames <- &image.RGBA{Pix: bytes.Clone(pixels), Stride: 4 * width, Rect: image.Rect(0, 0, width, height)}
+}
+
+// Close waits for the frames to be written.
+func (enc *frameEncoder) Close() error {
+	close(enc.frames)
+	return <-enc.done
+}
+
+// animationFrame is what a frame of an animation shows.
+type animationFrame struct {
+	// Number of nodes revealed by the replay, 0 for a camera path
+	position float32
+	target   rl.Vector2
+}
+
+// animationRecording is an animation being exported. A few frames are rendered at each frame of the
+// viewer, so that the window stays responsive and shows the progress.
+type animationRecording struct {
+	filename      string
+	width, height int32
+	camera        rl.Camera2D
+	frames        []animationFrame
+	next          int
+	// The camera follows the node revealed last by the replay
+	follow  bool
+	encoder *frameEncoder
+	// Puts the viewer back as it was before the export
+	restore func()
+}
+
+// recordingBudget is the time spent rendering frames at each frame of the viewer.
+const recordingBudget = 30 * time.Millisecond
+
+// animationCamera shows in the frames what the view shows, centered on the same point.
+func (e *treeEngine) animationCamera(width, height int32) rl.Camera2D {
+	camera := e.ecosystem.sys.Camera()
+	area := e.viewArea()
+	center := rl.GetScreenToWorld2D(rl.NewVector2(area.X+area.Width/2, area.Y+area.Height/2), camera)
+	camera.Zoom *= min(float32(width)/area.Width, float32(height)/area.Height)
+	camera.Target = center
+	camera.Offset = rl.NewVector2(float32(width)/2, float32(height)/2)
+	return camera
+}
+
+// startAnimation prepares the frames of the animation, rendered by recordAnimation.
+func (e *treeEngine) startAnimation(export animationExport) {
+	r := &animationRecording{
+		filename: export.filename,
+		width:    export.width,
+		height:   export.height,
+		camera:   e.animationCamera(export.width, export.height),
+		restore:  func() {},
+	}
+
+	var err error
+	switch export.source {
+	case replayAnimation:
+		e.replayFrames(r)
+	case bookmarksAnimation:
+		err = e.bookmarkFrames(r)
+	}
+	var writer frameWriter
+	if err == nil {
+		writer, err = newFrameWriter(export.filename)
+	}
+	if err != nil {
+		r.restore()
+		log.Error().Err(err).Str("file", export.filename).Msg("cannot export animation")
+		return
+	}
+	r.encoder = newFrameEncoder(writer)
+	e.recording = r
+}
+
+// recordAnimation renders the next frames of the animation being exported, and writes the file once
+// they are all rendered.
+func (e *treeEngine) recordAnimation() {
+	r := e.recording
+	start := time.Now()
+	for r.next < len(r.frames) && (r.next == 0 || time.Since(start) < recordingBudget) {
+		frame := r.frames[r.next]
+		camera := r.camera
+		camera.Target = frame.target
+		if frame.position > 0 {
+			e.replay.position = frame.position
+			e.applyReplay()
+			if count := int(e.replay.position); r.follow && count > 0 {
+				if center, ok := e.ecosystem.sys.NodeCenter(e.replay.order[count-1]); ok {
+					camera.Target = center
+				}
+			}
+		}
+		image := e.ecosystem.sys.RenderFrame(&e.ecosystem.world, camera, r.width, r.height)
+		r.encoder.Add(image)
+		rl.UnloadImage(image)
+		r.next++
+	}
+	if r.next < len(r.frames) {
+		return
+	}
+
+	e.recording = nil
+	r.restore()
+	if err := r.encoder.Close(); err != nil {
+		log.Error().Err(err).Str("file", r.filename).Msg("cannot export animation")
+		return
+	}
+	log.Info().Str("file", r.filename).Int("frames", len(r.frames)).Msg("animation exported")
+}
+
+// cancelAnimation stops the export, and deletes what was written.
+func (e *treeEngine) cancelAnimation() {
+	r := e.recording
+	e.recording = nil
+	r.restore()
+	err := r.encoder.Close()
+	if removeErr := r.encoder.writer.Remove(); err == nil {
+		err = removeErr
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("file", r.filename).Msg("canceled animation")
+		return
+	}
+	log.Info().Str("file", r.filename).Msg("animation export canceled")
+}
+
+// stepRecording renders the next frames of the animation being exported. Meanwhile the view is shown
+// without its controls, under the progress of the export.
+func (e *treeEngine) stepRecording() SceneID {
+	e.recordAnimation()
+
+	rl.BeginDrawing()
+	rl.ClearBackground(rl.White)
+	e.ecosystem.sys.CaptureInput()
+	e.ecosystem.sys.Update(&e.ecosystem.world)
+	if e.recording != nil {
+		e.drawAnimationProgress()
+	}
+	rl.EndDrawing()
+
+	return TreeSceneID
+}
+
+// drawAnimationProgress draws the progress of the export in the middle of the window, with a button
+// to cancel it.
+func (e *treeEngine) drawAnimationProgress() {
+	r := e.recording
+	panelRec := rl.NewRectangle(float32(rl.GetScreenWidth())/2-200, float32(rl.GetScreenHeight())/2-50, 400, 100)
+	gui.Panel(panelRec, "Exporting Animation")
+	status := fmt.Sprintf("frame %d / %d", r.next, len(r.frames))
+	gui.ProgressBar(rl.NewRectangle(panelRec.X+10, panelRec.Y+24+10, panelRec.Width-20, 24), "", "",
+		float32(r.next), 0, float32(len(r.frames)))
+	rl.DrawTextEx(e.font, status, rl.NewVector2(panelRec.X+10, panelRec.Y+24+44), 16, 0, rl.Black)
+	cancelSize := navButton("Cancel")
+	if gui.Button(rl.NewRectangle(panelRec.X+panelRec.Width-10-cancelSize.X, panelRec.Y+24+40, cancelSize.X, 24), "Cancel") {
+		e.cancelAnimation()
+	}
+}
+
+// replayFrames replays the tree at the speed of the replay, one frame at a time, then shows the
+// replay as it was once exported. The camera follows the node revealed last when the replay follows
+// it.
+func (e *treeEngine) replayFrames(r *animationRecording) {
+	saved := e.replay
+	if !saved.active {
+		e.startReplay()
+	}
+	e.replay.follow = false
+	r.follow = saved.follow
+	r.restore = func() {
+		e.replay.follow = saved.follow
+		if !saved.active {
+			e.stopReplay()
+			return
+		}
+		e.replay.position = saved.position
+		e.applyReplay()
+	}
+
+	nodes := float32(len(e.replay.order))
+	// the last frame stays a second
+	hold := animationFPS
+	perFrame := max(replaySpeeds[e.replay.speed]/animationFPS, nodes/float32(maxAnimationFrames-hold))
+	frames := int(math.Ceil(float64((nodes-1)/perFrame))) + 1
+	for f := range frames + hold {
+		r.frames = append(r.frames, animationFrame{position: min(1+float32(f)*perFrame, nodes), target: r.camera.Target})
+	}
+}
+
+// bookmarkFrames moves the camera from a bookmarked node to the next, in the order of the bookmarks,
+// pausing on each of them.
+func (e *treeEngine) bookmarkFrames(r *animationRecording) error {
+	centers := make([]rl.Vector2, 0)
+	for _, b := range e.currentBookmarks() {
+		if !e.ecosystem.sys.HasNode(b.Node) {
+			continue
+		}
+		if center, ok := e.ecosystem.sys.NodeCenter(b.Node); ok {
+			centers = append(centers, center)
+		}
+	}
+	if len(centers) < 2 {
+		return fmt.Errorf("the camera path needs at least 2 visible bookmarks, there are %d", len(centers))
+	}
+
+	hold := animationFPS / 2
+	move := min(2*animationFPS, (maxAnimationFrames-hold*len(centers))/(len(centers)-1))
+	move = max(move, 1)
+	for i, center := range centers {
+		for range hold {
+			r.frames = append(r.frames, animationFrame{target: center})
+		}
+		if i == len(centers)-1 {
+			break
+		}
+		for f := 1; f <= move; f++ {
+			// ease in and out
+			t := float32(f) / float32(move)
+			t = t * t * (3 - 2*t)
+			r.frames = append(r.frames, animationFrame{target: rl.Vector2Lerp(center, centers[i+1], t)})
+		}
+	}
+	return nil
+}
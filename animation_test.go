@@ -0,0 +1,135 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testFrames returns frames of a single color each.
+func testFrames(colors ...color.RGBA) []*image.RGBA {
+	frames := make([]*image.RGBA, 0, len(colors))
+	for _, c := range colors {
+		frame := image.NewRGBA(image.Rect(0, 0, 8, 6))
+		for i := 0; i < len(frame.Pix); i += 4 {
+			frame.Pix[i], frame.Pix[i+1], frame.Pix[i+2], frame.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func writeFrames(t *testing.T, filename string, frames []*image.RGBA) frameWriter {
+	t.Helper()
+	w, err := newFrameWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range frames {
+		if err := w.Add(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestGifWriter(t *testing.T) {
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	filename := filepath.Join(t.TempDir(), "x.gif")
+	w := writeFrames(t, filename, testFrames(colors...))
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	animation, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(animation.Image) != len(colors) {
+		t.Fatalf("gif has %d frames, want %d", len(animation.Image), len(colors))
+	}
+	if want := []int{5, 5, 5}; !slices.Equal(animation.Delay, want) {
+		t.Errorf("gif delays = %v, want %v", animation.Delay, want)
+	}
+	if animation.LoopCount != 0 {
+		t.Errorf("gif loop count = %d, want 0 (forever)", animation.LoopCount)
+	}
+	if animation.Config.Width != 8 || animation.Config.Height != 6 {
+		t.Errorf("gif size = %dx%d, want 8x6", animation.Config.Width, animation.Config.Height)
+	}
+	for i, frame := range animation.Image {
+		want := color.Palette(palette.Plan9).Convert(colors[i])
+		if got := frame.At(3, 2); got != want {
+			t.Errorf("frame %d color = %v, want %v", i, got, want)
+		}
+	}
+
+	if err := w.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("gif not removed: %v", err)
+	}
+}
+
+func TestPngSequence(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     []string
+	}{
+		{"x.png", []string{"x-0001.png", "x-0002.png", "x-0003.png"}},
+		{"replay.PNG", []string{"replay-0001.png", "replay-0002.png", "replay-0003.png"}},
+		{"run.1.png", []string{"run.1-0001.png", "run.1-0002.png", "run.1-0003.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			dir := t.TempDir()
+			frames := testFrames(color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255})
+			w := writeFrames(t, filepath.Join(dir, tt.filename), frames)
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0, len(entries))
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("png files = %v, want %v", names, tt.want)
+			}
+
+			f, err := os.Open(filepath.Join(dir, tt.want[1]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			img, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r, g, b, _ := img.At(0, 0).RGBA(); r != 0 || g != 0xffff || b != 0 {
+				t.Errorf("second frame color = %v, want green", img.At(0, 0))
+			}
+
+			if err := w.Remove(); err != nil {
+				t.Fatal(err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("files left after Remove: %v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/systems"
+)
+
+const legendPanelWidth = 240
+
+type statusCount struct {
+	Status string `json:"status"`
+	Nodes  int    `json:"nodes"`
+}
+
+// prunedCounts are the numbers of nodes whose subtree is not explored, by reason.
+type prunedCounts struct {
+	ByBound    int `json:"by_bound"`
+	Infeasible int `json:"infeasible"`
+	// Solutions and incumbents
+	Solution int `json:"solution"`
+}
+
+func (p prunedCounts) String() string {
+	return fmt.Sprintf("by bound %d, infeasible %d, solution %d", p.ByBound, p.Infeasible, p.Solution)
+}
+
+// branchAndBoundSummary sums up the statuses of the nodes of a branch and bound tree, given by their
+// status data key.
+type branchAndBoundSummary struct {
+	Statuses []statusCount `json:"statuses"`
+	Pruned   prunedCounts  `json:"pruned"`
+	// Best solution, the last incumbent
+	Best      *uint64  `json:"best_solution,omitempty"`
+	Objective *float64 `json:"best_objective,omitempty"`
+	// Bound of the root, and its gap with the best objective in percent of the objective
+	Bound *float64 `json:"root_bound,omitempty"`
+	Gap   *float64 `json:"gap,omitempty"`
+
+	tree   *GraphView
+	counts map[systems.NodeStatus]int
+}
+
+// summarizeBranchAndBound returns nil when no node has a status.
+func summarizeBranchAndBound(tree *GraphView) *branchAndBoundSummary {
+	counts := make(map[systems.NodeStatus]int)
+	for _, n := range tree.Nodes {
+		if status := systems.StatusOf(n); status != systems.NoStatus {
+			counts[status]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	s := &branchAndBoundSummary{tree: tree, counts: counts, Statuses: make([]statusCount, 0, len(counts))}
+	for _, status := range systems.NodeStatuses {
+		if counts[status] > 0 {
+			s.Statuses = append(s.Statuses, statusCount{Status: status.String(), Nodes: counts[status]})
+		}
+	}
+	s.Pruned = prunedCounts{
+		ByBound:    counts[systems.PrunedByBoundStatus],
+		Infeasible: counts[systems.InfeasibleStatus],
+		Solution:   counts[systems.SolutionStatus] + counts[systems.IncumbentStatus],
+	}
+
+	if i := systems.FindBestSolution(tree); i != -1 {
+		best := tree.Nodes[i]
+		s.Best = &best.Id
+		if v, ok := best.Value(systems.ObjectiveKey); ok {
+			if objective, ok := systems.NumericValue(v); ok {
+				s.Objective = &objective
+			}
+		}
+	}
+	if bound, ok := systems.RootBound(tree); ok {
+		s.Bound = &bound
+		if s.Objective != nil && *s.Objective != 0 {
+			gap := math.Abs(*s.Objective-bound) / math.Abs(*s.Objective) * 100
+			s.Gap = &gap
+		}
+	}
+	return s
+}
+
+func (s *branchAndBoundSummary) statusesText() string {
+	texts := make([]string, 0, len(s.Statuses))
+	for _, c := range s.Statuses {
+		texts = append(texts, fmt.Sprintf("%s %d", c.Status, c.Nodes))
+	}
+	return strings.Join(texts, ", ")
+}
+
+func (s *branchAndBoundSummary) bestText() string {
+	if s.Best == nil {
+		return "none"
+	}
+	if s.Objective == nil {
+		return fmt.Sprintf("node %d", *s.Best)
+	}
+	return fmt.Sprintf("node %d, objective %.6g", *s.Best, *s.Objective)
+}
+
+func (s *branchAndBoundSummary) boundText() string {
+	if s.Bound == nil {
+		return "none"
+	}
+	if s.Gap == nil {
+		return fmt.Sprintf("%.6g", *s.Bound)
+	}
+	return fmt.Sprintf("%.6g, gap %.2f%%", *s.Bound, *s.Gap)
+}
+
+// currentBranchAndBound returns the summary of the displayed tree, nil when it has no status,
+// computed again when the tree changes.
+func (e *treeEngine) currentBranchAndBound() *branchAndBoundSummary {
+	tree := e.displayedTree().Tree
+	if e.branchAndBoundTree != tree {
+		e.branchAndBoundTree = tree
+		e.branchAndBound = summarizeBranchAndBound(tree)
+	}
+	return e.branchAndBound
+}
+
+// drawLegendPanel draws, at the bottom right of the view, the colors of the statuses with their
+// number of nodes, the pruned nodes by reason, the bound of the root with its gap, and the best
+// solution, which is visited when clicked.
+// It returns the area of the panel, empty when the tree has no status.
+func (e *treeEngine) drawLegendPanel() rl.Rectangle {
+	summary := e.currentBranchAndBound()
+	if summary == nil {
+		return rl.Rectangle{}
+	}
+
+	area := e.viewArea()
+	bottom := area.Y + area.Height - 30
+	if e.replay.active {
+		bottom -= replayBarHeight + 10
+	}
+	if e.showChart {
+		bottom -= chartHeight + 10
+	}
+	height := float32(24+10+statsLineHeight*(len(systems.NodeStatuses)+5)) + 10
+	panelRec := rl.NewRectangle(area.X+area.Width-legendPanelWidth-10, bottom-height, legendPanelWidth, height)
+	gui.Panel(panelRec, "Branch and Bound")
+
+	x := panelRec.X + 10
+	y := panelRec.Y + 24 + 10
+	for _, status := range systems.NodeStatuses {
+		rl.DrawRectangleLinesEx(rl.NewRectangle(x, y+2, 14, 14), 3, systems.Palette.Status[status])
+		rl.DrawTextEx(e.font, status.String(), rl.NewVector2(x+22, y), 16, 0, rl.Black)
+		count := fmt.Sprint(summary.counts[status])
+		countWidth := rl.MeasureTextEx(e.font, count, 16, 0).X
+		rl.DrawTextEx(e.font, count, rl.NewVector2(panelRec.X+panelRec.Width-10-countWidth, y), 16, 0, rl.Black)
+		y += statsLineHeight
+	}
+
+	// Best solution path
+	rl.DrawLineEx(rl.NewVector2(x, y+9), rl.NewVector2(x+14, y+9), 4, systems.Palette.Status[systems.IncumbentStatus])
+	rl.DrawTextEx(e.font, "path to the best solution", rl.NewVector2(x+22, y), 16, 0, rl.Black)
+	y += statsLineHeight
+
+	rl.DrawTextEx(e.font, "Pruned", rl.NewVector2(x, y), 16, 0, rl.DarkGray)
+	y += statsLineHeight
+	rl.DrawTextEx(e.font, summary.Pruned.String(), rl.NewVector2(x, y), 16, 0, rl.Black)
+	y += statsLineHeight
+	rl.DrawTextEx(e.font, "Root bound: "+summary.boundText(), rl.NewVector2(x, y), 16, 0, rl.Black)
+	y += statsLineHeight
+
+	bestRec := rl.NewRectangle(panelRec.X+2, y-2, panelRec.Width-4, statsLineHeight)
+	textColor := rl.Black
+	if summary.Best != nil && rl.CheckCollisionPointRec(rl.GetMousePosition(), bestRec) {
+		rl.DrawRectangleRec(bestRec, rl.NewColor(169, 181, 223, 120))
+		textColor = rl.NewColor(45, 51, 107, 255)
+		if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+			e.ecosystem.sys.GoToNode(*summary.Best)
+		}
+	}
+	rl.DrawTextEx(e.font, "Best: "+summary.bestText(), rl.NewVector2(x, y), 16, 0, textColor)
+
+	return panelRec
+}
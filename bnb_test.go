@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSummarizeBranchAndBound(t *testing.T) {
+	if s := summarizeBranchAndBound(testTree(testNode{0, -1, nil}, testNode{1, 0, nil}).Tree); s != nil {
+		t.Errorf("summarizeBranchAndBound(no status) = %+v, want nil", s)
+	}
+
+	status := func(s string) map[string]any { return map[string]any{"status": s} }
+	tree := testTree(
+		testNode{0, -1, map[string]any{"status": "branched", "bound": 8}},
+		testNode{1, 0, status("branched")},
+		testNode{2, 0, status("Pruned_By_Bound")},
+		testNode{3, 1, status("infeasible")},
+		testNode{4, 1, map[string]any{"status": "incumbent", "objective": 10}},
+		testNode{5, 1, map[string]any{"status": "solution", "objective": 12}},
+		testNode{6, 0, status("open")},
+		testNode{7, 0, status("pruned-by-bound")},
+		testNode{8, 0, status("unknown")},
+	).Tree
+
+	s := summarizeBranchAndBound(tree)
+	if s == nil {
+		t.Fatal("summarizeBranchAndBound() = nil")
+	}
+	want := []statusCount{
+		{"open", 1}, {"branched", 2}, {"pruned-by-bound", 2}, {"infeasible", 1}, {"solution", 1}, {"incumbent", 1},
+	}
+	if !slices.Equal(s.Statuses, want) {
+		t.Errorf("statuses = %v, want %v", s.Statuses, want)
+	}
+	if want := (prunedCounts{ByBound: 2, Infeasible: 1, Solution: 2}); s.Pruned != want {
+		t.Errorf("pruned = %+v, want %+v", s.Pruned, want)
+	}
+	if got := s.bestText(); got != "node 4, objective 10" {
+		t.Errorf("best = %q", got)
+	}
+	if got := s.boundText(); got != "8, gap 20.00%" {
+		t.Errorf("bound = %q", got)
+	}
+}
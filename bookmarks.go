@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/ncruces/zenity"
+	"github.com/phuslu/log"
+)
+
+// bookmark is a node marked with a note.
+type bookmark struct {
+	Node uint64 `json:"node"`
+	Note string `json:"note"`
+}
+
+// treeBookmarks are the bookmarks of the trees of a file, by tree name.
+type treeBookmarks map[string][]bookmark
+
+// bookmarksFilename is the sidecar file of the bookmarks of a tree file.
+func bookmarksFilename(file string) string {
+	return file + ".bookmarks.json"
+}
+
+func readBookmarks(file string) (treeBookmarks, error) {
+	content, err := os.ReadFile(bookmarksFilename(file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(treeBookmarks), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	bookmarks := make(treeBookmarks)
+	if err := json.Unmarshal(content, &bookmarks); err != nil {
+		return nil, fmt.Errorf("invalid bookmarks %s: %w", bookmarksFilename(file), err)
+	}
+	return bookmarks, nil
+}
+
+func writeBookmarks(file string, bookmarks treeBookmarks) error {
+	content, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(bookmarksFilename(file), append(content, '\n'), 0o644)
+}
+
+// bookmarkStore keeps the bookmarks of the loaded files, read from their sidecar files when first
+// needed. Trees read from stdin have bookmarks in memory only.
+type bookmarkStore struct {
+	files map[string]treeBookmarks
+}
+
+func newBookmarkStore() *bookmarkStore {
+	return &bookmarkStore{files: make(map[string]treeBookmarks)}
+}
+
+func (s *bookmarkStore) file(file string) treeBookmarks {
+	bookmarks, ok := s.files[file]
+	if ok {
+		return bookmarks
+	}
+	bookmarks = make(treeBookmarks)
+	if file != "" {
+		read, err := readBookmarks(file)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot read bookmarks")
+		} else {
+			bookmarks = read
+		}
+	}
+	s.files[file] = bookmarks
+	return bookmarks
+}
+
+func (s *bookmarkStore) Tree(file, tree string) []bookmark {
+	return s.file(file)[tree]
+}
+
+// Set replaces the bookmarks of the tree, and saves them in the sidecar file.
+func (s *bookmarkStore) Set(file, tree string, bookmarks []bookmark) {
+	all := s.file(file)
+	if len(bookmarks) == 0 {
+		delete(all, tree)
+	} else {
+		all[tree] = bookmarks
+	}
+	if file == "" {
+		log.Warn().Msg("bookmarks of stdin are not saved")
+		return
+	}
+	if err := writeBookmarks(file, all); err != nil {
+		log.Error().Err(err).Msg("cannot save bookmarks")
+	}
+}
+
+// Merge adds the bookmarks of nodes without one, without saving them.
+func (s *bookmarkStore) Merge(file, tree string, bookmarks []bookmark) {
+	all := s.file(file)
+	for _, b := range bookmarks {
+		if !slices.ContainsFunc(all[tree], func(other bookmark) bool { return other.Node == b.Node }) {
+			all[tree] = append(all[tree], b)
+		}
+	}
+}
+
+// currentBookmarks returns the bookmarks of the current tree.
+func (e *treeEngine) currentBookmarks() []bookmark {
+	return e.bookmarks.Tree(e.app.store.File(int(e.app.currentTree)), e.app.treeNames[e.app.currentTree])
+}
+
+func (e *treeEngine) setCurrentBookmarks(bookmarks []bookmark) {
+	e.bookmarks.Set(e.app.store.File(int(e.app.currentTree)), e.app.treeNames[e.app.currentTree], bookmarks)
+	e.applyBookmarks()
+}
+
+// applyBookmarks shows the flags of the bookmarks of the current tree.
+func (e *treeEngine) applyBookmarks() {
+	bookmarked := make(map[uint64]bool)
+	for _, b := range e.currentBookmarks() {
+		bookmarked[b.Node] = true
+	}
+	e.ecosystem.sys.SetBookmarked(bookmarked)
+}
+
+// editBookmark asks for the note of the bookmark of the node, creating it if needed.
+func (e *treeEngine) editBookmark(id uint64) {
+	bookmarks := slices.Clone(e.currentBookmarks())
+	i := slices.IndexFunc(bookmarks, func(b bookmark) bool { return b.Node == id })
+	note := ""
+	if i != -1 {
+		note = bookmarks[i].Note
+	}
+
+	note, err := zenity.Entry(fmt.Sprintf("Note of node %d", id),
+		zenity.Title("Bookmark"),
+		zenity.EntryText(note))
+	if err != nil {
+		log.Info().Err(err).Msg("bookmark")
+		return
+	}
+
+	if i == -1 {
+		bookmarks = append(bookmarks, bookmark{Node: id, Note: note})
+	} else {
+		bookmarks[i].Note = note
+	}
+	e.setCurrentBookmarks(bookmarks)
+}
+
+func (e *treeEngine) removeBookmark(id uint64) {
+	bookmarks := slices.DeleteFunc(slices.Clone(e.currentBookmarks()), func(b bookmark) bool { return b.Node == id })
+	e.setCurrentBookmarks(bookmarks)
+}
+
+func (e *treeEngine) isBookmarked(id uint64) bool {
+	return slices.ContainsFunc(e.currentBookmarks(), func(b bookmark) bool { return b.Node == id })
+}
+
+const bookmarksPanelWidth = 340
+
+// drawBookmarksPanel lists the bookmarks of the current tree at (x, y). Clicking a bookmark goes to
+// its node. It returns the area of the panel, empty when hidden.
+func (e *treeEngine) drawBookmarksPanel(x, y float32) rl.Rectangle {
+	if !e.showBookmarks {
+		return rl.Rectangle{}
+	}
+	bookmarks := e.currentBookmarks()
+
+	lineHeight := float32(20)
+	height := 24 + 10 + lineHeight*float32(max(len(bookmarks), 1)) + 10
+	panelRec := rl.NewRectangle(x, y, bookmarksPanelWidth, height)
+	gui.Panel(panelRec, "Bookmarks")
+
+	x = panelRec.X + 10
+	y = panelRec.Y + 24 + 10
+	if len(bookmarks) == 0 {
+		rl.DrawTextEx(e.font, "right click a node to bookmark it", rl.NewVector2(x, y), 16, 0, rl.DarkGray)
+	}
+
+	mouse := rl.GetMousePosition()
+	for _, b := range bookmarks {
+		row := rl.NewRectangle(panelRec.X, y, panelRec.Width, lineHeight)
+		color := rl.Black
+		if rl.CheckCollisionPointRec(mouse, row) {
+			color = rl.NewColor(45, 51, 107, 255)
+			rl.DrawRectangleRec(row, rl.NewColor(169, 181, 223, 120))
+			if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+				if !e.ecosystem.sys.HasNode(b.Node) {
+					log.Warn().Uint64("node", b.Node).Msg("bookmarked node is not visible")
+				}
+				e.ecosystem.sys.GoToNode(b.Node)
+			}
+		}
+		text := fmt.Sprintf("%d: %s", b.Node, b.Note)
+		rl.DrawTextEx(e.font, truncateText(text, 40), rl.NewVector2(x, y), 16, 0, color)
+		y += lineHeight
+	}
+
+	return panelRec
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/phuslu/log"
+)
+
+const breadcrumbsHeight = 28
+
+// breadcrumbs keeps the parents of the nodes of the displayed tree, to list the ancestors of the
+// selected node without walking the edges every frame.
+type breadcrumbs struct {
+	tree    *GraphView
+	parents []int
+}
+
+// path returns the indices of the nodes from the root to the node, included.
+func (b *breadcrumbs) path(tree *GraphView, id uint64) []int {
+	if b.tree != tree {
+		b.tree = tree
+		b.parents = tree.ParentIndices()
+	}
+	i, ok := tree.Lookup[id]
+	if !ok {
+		return nil
+	}
+	path := make([]int, 0)
+	for ; i != -1; i = b.parents[i] {
+		path = append(path, i)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// crumb returns the label of a node in the breadcrumbs: its title, and the value of the key.
+func crumb(n *DisplayableNode, key string) string {
+	label := fmt.Sprintf("Node %d", n.Id)
+	if key == "" {
+		return label
+	}
+	if v, ok := n.Value(key); ok {
+		label += fmt.Sprintf(" (%v)", v)
+	}
+	return label
+}
+
+// drawBreadcrumbs draws, below the top bar, the path from the root to the selected node. Clicking a
+// node of the path goes to it. It returns the area of the strip, empty when no node is selected.
+func (e *treeEngine) drawBreadcrumbs() rl.Rectangle {
+	id, ok := e.ecosystem.sys.SelectedNode()
+	if !ok {
+		return rl.Rectangle{}
+	}
+	tree := e.displayedTree().Tree
+	path := e.crumbs.path(tree, id)
+	if len(path) == 0 {
+		return rl.Rectangle{}
+	}
+
+	stripRec := rl.NewRectangle(0, 40, float32(rl.GetScreenWidth()), breadcrumbsHeight)
+	rl.DrawRectangleRec(stripRec, rl.NewColor(246, 248, 250, 230))
+	rl.DrawLineEx(
+		rl.NewVector2(stripRec.X, stripRec.Y+stripRec.Height),
+		rl.NewVector2(stripRec.X+stripRec.Width, stripRec.Y+stripRec.Height),
+		1, rl.NewColor(209, 217, 224, 255))
+
+	offsetX := float32(10)
+	keySize := navButton("path with")
+	gui.Label(rl.NewRectangle(offsetX, stripRec.Y+2, keySize.X, stripRec.Height-4), "path with")
+	offsetX += keySize.X
+	keyRec := rl.NewRectangle(offsetX, stripRec.Y+2, 100, stripRec.Height-4)
+	if gui.TextBox(keyRec, &e.crumbKey, 32, e.crumbKeyEdit) {
+		e.crumbKeyEdit = !e.crumbKeyEdit
+	}
+	offsetX += keyRec.Width + 10
+
+	key := strings.TrimSpace(e.crumbKey)
+	labels := make([]string, len(path))
+	widths := make([]float32, len(path))
+	separator := rl.MeasureTextEx(e.font, " > ", 16, 0).X
+	total := float32(0)
+	for k, i := range path {
+		labels[k] = crumb(tree.Nodes[i], key)
+		widths[k] = rl.MeasureTextEx(e.font, labels[k], 16, 0).X
+		total += widths[k] + separator
+	}
+
+	// The closest ancestors are kept when the path does not fit, the others are elided after the
+	// root
+	ellipsis := rl.MeasureTextEx(e.font, "...", 16, 0).X + separator
+	first := 1
+	for first < len(path)-1 && offsetX+total > stripRec.Width-10 {
+		if first == 1 {
+			total += ellipsis
+		}
+		total -= widths[first] + separator
+		first++
+	}
+
+	mouse := rl.GetMousePosition()
+	y := stripRec.Y + (stripRec.Height-16)/2
+	for k, i := range path {
+		if k > 0 && k < first {
+			if k == 1 {
+				rl.DrawTextEx(e.font, "...", rl.NewVector2(offsetX, y), 16, 0, rl.DarkGray)
+				offsetX += ellipsis - separator
+			} else {
+				continue
+			}
+		} else {
+			rec := rl.NewRectangle(offsetX-2, stripRec.Y+2, widths[k]+4, stripRec.Height-4)
+			color := rl.Black
+			if k == len(path)-1 {
+				color = rl.NewColor(45, 51, 107, 255)
+			}
+			if rl.CheckCollisionPointRec(mouse, rec) {
+				color = rl.NewColor(45, 51, 107, 255)
+				rl.DrawRectangleRec(rec, rl.NewColor(169, 181, 223, 120))
+				if rl.IsMouseButtonPressed(rl.MouseButtonLeft) && !gui.IsLocked() {
+					log.Info().Uint64("node", tree.Nodes[i].Id).Msg("go to ancestor")
+					e.ecosystem.sys.GoToNode(tree.Nodes[i].Id)
+				}
+			}
+			rl.DrawTextEx(e.font, labels[k], rl.NewVector2(offsetX, y), 16, 0, color)
+			offsetX += widths[k]
+		}
+		if k < len(path)-1 {
+			rl.DrawTextEx(e.font, " > ", rl.NewVector2(offsetX, y), 16, 0, rl.Gray)
+			offsetX += separator
+		}
+	}
+
+	return stripRec
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/systems"
+)
+
+const chartHeight = 280
+
+// Colors of the series of the chart, in the order of the keys
+var chartColors = []rl.Color{
+	rl.NewColor(31, 119, 180, 255),
+	rl.NewColor(255, 127, 14, 255),
+	rl.NewColor(44, 160, 44, 255),
+	rl.NewColor(214, 39, 40, 255),
+	rl.NewColor(148, 103, 189, 255),
+	rl.NewColor(140, 86, 75, 255),
+}
+
+type chartPoint struct {
+	node uint64
+	x, y float64
+}
+
+// chartData is the values of data keys of the nodes, in the order they were explored.
+type chartData struct {
+	tree   *GraphView
+	keys   string
+	xKey   string
+	series [][]chartPoint
+	names  []string
+	// Bounds of every series
+	minX, maxX, minY, maxY float64
+	// Abscissa of every node, to mark the selected one
+	xs map[uint64]float64
+}
+
+// newChartData gives every node its rank in the exploration order as abscissa, or the value of
+// xKey when given, nodes without it being left out.
+func newChartData(tree *GraphView, keys, xKey string) *chartData {
+	c := &chartData{
+		tree: tree, keys: keys, xKey: xKey,
+		names: duplicateKeys(keys),
+		xs:    make(map[uint64]float64),
+		minX:  math.Inf(1), maxX: math.Inf(-1), minY: math.Inf(1), maxY: math.Inf(-1),
+	}
+	c.series = make([][]chartPoint, len(c.names))
+
+	for rank, id := range replayOrder(tree.Nodes, xKey) {
+		n := tree.NodeForId(id)
+		x := float64(rank)
+		if xKey != "" {
+			v, ok := n.Value(xKey)
+			if !ok {
+				continue
+			}
+			if x, ok = systems.NumericValue(v); !ok {
+				continue
+			}
+		}
+		c.xs[id] = x
+		for k, key := range c.names {
+			v, ok := n.Value(key)
+			if !ok {
+				continue
+			}
+			y, ok := systems.NumericValue(v)
+			if !ok || math.IsNaN(y) || math.IsInf(y, 0) {
+				continue
+			}
+			c.series[k] = append(c.series[k], chartPoint{node: id, x: x, y: y})
+			c.minX, c.maxX = min(c.minX, x), max(c.maxX, x)
+			c.minY, c.maxY = min(c.minY, y), max(c.maxY, y)
+		}
+	}
+	if c.maxX == c.minX {
+		c.minX, c.maxX = c.minX-1, c.maxX+1
+	}
+	if c.maxY == c.minY {
+		c.minY, c.maxY = c.minY-1, c.maxY+1
+	}
+	return c
+}
+
+func (c *chartData) empty() bool {
+	for _, s := range c.series {
+		if len(s) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// currentChart returns the chart of the displayed tree, computed again when the keys change. The
+// keys being edited are used once validated.
+func (e *treeEngine) currentChart() *chartData {
+	tree := e.displayedTree().Tree
+	if e.chart != nil && e.chart.tree == tree && (e.chartKeysEdit || e.chartXKeyEdit) {
+		return e.chart
+	}
+	keys, xKey := strings.TrimSpace(e.chartKeys), strings.TrimSpace(e.chartXKey)
+	if e.chart == nil || e.chart.tree != tree || e.chart.keys != keys || e.chart.xKey != xKey {
+		e.chart = newChartData(tree, keys, xKey)
+	}
+	return e.chart
+}
+
+// drawChartPanel draws, at the bottom of the view and above the replay bar, the values of the data
+// keys of the nodes against their exploration order or time. Clicking a point goes to its node, and
+// the selected node is marked. It returns the area of the panel, empty when hidden.
+func (e *treeEngine) drawChartPanel() rl.Rectangle {
+	if !e.showChart {
+		return rl.Rectangle{}
+	}
+	area := e.viewArea()
+	bottom := area.Y + area.Height - 30
+	if e.replay.active {
+		bottom -= replayBarHeight + 10
+	}
+	panelRec := rl.NewRectangle(area.X+10, bottom-chartHeight, area.Width-20, chartHeight)
+	gui.Panel(panelRec, "Chart")
+
+	// Keys
+	x := panelRec.X + 10
+	y := panelRec.Y + 24 + 6
+	keysSize := navButton("keys")
+	gui.Label(rl.NewRectangle(x, y, keysSize.X, 24), "keys")
+	x += keysSize.X
+	keysRec := rl.NewRectangle(x, y, 220, 24)
+	if gui.TextBox(keysRec, &e.chartKeys, 64, e.chartKeysEdit) {
+		e.chartKeysEdit = !e.chartKeysEdit
+	}
+	x += keysRec.Width + 10
+	xKeySize := navButton("against")
+	gui.Label(rl.NewRectangle(x, y, xKeySize.X, 24), "against")
+	x += xKeySize.X
+	xKeyRec := rl.NewRectangle(x, y, 100, 24)
+	if gui.TextBox(xKeyRec, &e.chartXKey, 32, e.chartXKeyEdit) {
+		e.chartXKeyEdit = !e.chartXKeyEdit
+	}
+	x += xKeyRec.Width + 10
+
+	chart := e.currentChart()
+	xLabel := "exploration order"
+	if chart.xKey != "" {
+		xLabel = chart.xKey
+	}
+	for k, name := range chart.names {
+		color := chartColors[k%len(chartColors)]
+		rl.DrawRectangleRec(rl.NewRectangle(x, y+6, 12, 12), color)
+		rl.DrawTextEx(e.font, name, rl.NewVector2(x+16, y+4), 16, 0, rl.Black)
+		x += 16 + rl.MeasureTextEx(e.font, name, 16, 0).X + 10
+	}
+	y += 24 + 6
+
+	plotRec := rl.NewRectangle(panelRec.X+70, y, panelRec.Width-90, panelRec.Y+panelRec.Height-y-26)
+	rl.DrawRectangleLinesEx(plotRec, 1, rl.LightGray)
+	if chart.empty() {
+		msg := "type comma separated numeric data keys, e.g. incumbent, bound"
+		rl.DrawTextEx(e.font, msg, rl.NewVector2(plotRec.X+10, plotRec.Y+10), 16, 0, rl.DarkGray)
+		return panelRec
+	}
+
+	toScreen := func(p chartPoint) rl.Vector2 {
+		return rl.NewVector2(
+			plotRec.X+plotRec.Width*float32((p.x-chart.minX)/(chart.maxX-chart.minX)),
+			plotRec.Y+plotRec.Height*float32(1-(p.y-chart.minY)/(chart.maxY-chart.minY)))
+	}
+	axisLabel := func(v float64) string { return fmt.Sprintf("%.4g", v) }
+	rl.DrawTextEx(e.font, axisLabel(chart.maxY), rl.NewVector2(panelRec.X+10, plotRec.Y), 16, 0, rl.DarkGray)
+	rl.DrawTextEx(e.font, axisLabel(chart.minY), rl.NewVector2(panelRec.X+10, plotRec.Y+plotRec.Height-16), 16, 0, rl.DarkGray)
+	rl.DrawTextEx(e.font, axisLabel(chart.minX), rl.NewVector2(plotRec.X, plotRec.Y+plotRec.Height+4), 16, 0, rl.DarkGray)
+	maxLabel := axisLabel(chart.maxX)
+	rl.DrawTextEx(e.font, maxLabel,
+		rl.NewVector2(plotRec.X+plotRec.Width-rl.MeasureTextEx(e.font, maxLabel, 16, 0).X, plotRec.Y+plotRec.Height+4), 16, 0, rl.DarkGray)
+	labelWidth := rl.MeasureTextEx(e.font, xLabel, 16, 0).X
+	rl.DrawTextEx(e.font, xLabel, rl.NewVector2(plotRec.X+(plotRec.Width-labelWidth)/2, plotRec.Y+plotRec.Height+4), 16, 0, rl.DarkGray)
+
+	// Selected node
+	selected, hasSelected := e.ecosystem.sys.SelectedNode()
+	if sx, ok := chart.xs[selected]; hasSelected && ok {
+		top := toScreen(chartPoint{x: sx, y: chart.maxY})
+		rl.DrawLineEx(top, rl.NewVector2(top.X, plotRec.Y+plotRec.Height), 2, systems.Palette.Selected)
+	}
+
+	// Points closer than a pixel to the last drawn one are skipped, large trees having many nodes
+	// per pixel
+	mouse := rl.GetMousePosition()
+	hovered, hoveredSeries, hoveredDistance := chartPoint{}, -1, float32(8*8)
+	for k, series := range chart.series {
+		color := chartColors[k%len(chartColors)]
+		last := rl.NewVector2(-1, -1)
+		for i, p := range series {
+			pos := toScreen(p)
+			if d := rl.Vector2DistanceSqr(pos, mouse); d < hoveredDistance {
+				hovered, hoveredSeries, hoveredDistance = p, k, d
+			}
+			if i > 0 && rl.Vector2DistanceSqr(pos, last) < 1 {
+				continue
+			}
+			if i > 0 {
+				rl.DrawLineEx(last, pos, 1, color)
+			}
+			if len(series) < 500 {
+				rl.DrawCircleV(pos, 2, color)
+			}
+			last = pos
+		}
+		if hasSelected {
+			for _, p := range series {
+				if p.node == selected {
+					rl.DrawCircleV(toScreen(p), 5, systems.Palette.Selected)
+				}
+			}
+		}
+	}
+
+	if hoveredSeries >= 0 && rl.CheckCollisionPointRec(mouse, panelRec) {
+		pos := toScreen(hovered)
+		rl.DrawCircleV(pos, 5, chartColors[hoveredSeries%len(chartColors)])
+		label := fmt.Sprintf("node %d: %s = %v", hovered.node, chart.names[hoveredSeries], hovered.y)
+		size := rl.MeasureTextEx(e.font, label, 16, 0)
+		labelPos := rl.NewVector2(min(pos.X+8, plotRec.X+plotRec.Width-size.X), max(pos.Y-size.Y-8, plotRec.Y))
+		rl.DrawRectangleRec(rl.NewRectangle(labelPos.X-2, labelPos.Y, size.X+4, size.Y), rl.NewColor(255, 255, 255, 220))
+		rl.DrawTextEx(e.font, label, labelPos, 16, 0, rl.Black)
+		if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+			e.ecosystem.sys.GoToNode(hovered.node)
+		}
+	}
+
+	return panelRec
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gverger/optimview/render"
+	"github.com/gverger/optimview/systems"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+// Headless commands don't open any window, so that they can run in scripts and CI. The list is
+// built in init since the commands print their usage from it.
+func init() {
+	commands = []command{
+		{name: "view", args: "[FILE...]", summary: "display trees, opening a file dialog when no file is given", run: runView},
+		{name: "compare", args: "FILE_A FILE_B", summary: "display the trees of two files merged, coloring their differences", run: runCompare},
+		{name: "render", args: "FILE", summary: "draw a tree to a png, svg or pdf file, without opening any window", run: runRender},
+		{name: "stats", args: "FILE...", summary: "print statistics about the trees: size, depth, branching, data values", run: runStats},
+		{name: "diff-stats", args: "OLD NEW", summary: "compare the statistics of the trees of two files, failing when they grew too much", run: runDiffStats},
+		{name: "validate", args: "FILE...", summary: "check that the placements of the nodes don't overlap nor exceed the container", run: runValidate},
+		{name: "generate", args: "", summary: "generate a random tree, for testing", run: runGenerate},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: optimview [COMMAND] [OPTIONS] [FILE...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without command, the arguments are passed to view.")
+	fmt.Fprintln(w, "Run 'optimview COMMAND -h' for the options of a command.")
+}
+
+// runCommand runs the command named by the first argument. Without a known command name, the
+// arguments are given to the view command, so that `optimview tree.json` keeps working.
+func runCommand(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			printUsage(os.Stdout)
+			return nil
+		}
+		if c, ok := findCommand(args[0]); ok {
+			return ignoreHelp(c.run(args[1:]))
+		}
+	}
+	return ignoreHelp(runView(args))
+}
+
+// ignoreHelp hides the error returned when -h is given, the usage being already printed.
+func ignoreHelp(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+func newFlagSet(c string) *flag.FlagSet {
+	fs := flag.NewFlagSet(c, flag.ContinueOnError)
+	fs.Usage = func() {
+		cmd, _ := findCommand(c)
+		fmt.Fprintf(fs.Output(), "Usage: optimview %s [OPTIONS] %s\n\n%s\n\nOptions:\n", c, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags wherever they are, the standard library stopping at the first
+// positional argument. It returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// optionalUint64 is a flag that records whether it was set.
+type optionalUint64 struct {
+	value *uint64
+}
+
+func (o *optionalUint64) String() string {
+	if o.value == nil {
+		return ""
+	}
+	return fmt.Sprint(*o.value)
+}
+
+func (o *optionalUint64) Set(s string) error {
+	var v uint64
+	if _, err := fmt.Sscan(s, &v); err != nil {
+		return fmt.Errorf("invalid node id %q", s)
+	}
+	o.value = &v
+	return nil
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func addViewerFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&config.DebugMode, "debug", config.DebugMode, "display debug information")
+	fs.BoolVar(&config.Prefetch, "prefetch", config.Prefetch, "parse the next tree of archives in the background")
+	fs.IntVar(&config.TreeCacheSize, "cache-size", config.TreeCacheSize, "number of parsed trees kept in memory")
+	fs.IntVar(&config.ViewCacheSize, "view-cache-size", config.ViewCacheSize, "number of views of trees kept alive for instant switching")
+	fs.IntVar(&config.ViewCacheMemory, "view-cache-memory", config.ViewCacheMemory, "memory used by the views kept alive, in MB")
+}
+
+func layoutFlag(fs *flag.FlagSet) *string {
+	names := make([]string, 0, len(layouts))
+	for _, l := range layouts {
+		names = append(names, string(l))
+	}
+	return fs.String("layout", string(config.Layout), "tree layout: "+strings.Join(names, ", "))
+}
+
+func setLayout(name string) error {
+	if !slices.Contains(layouts, Layout(name)) {
+		return fmt.Errorf("unknown layout %q", name)
+	}
+	config.Layout = Layout(name)
+	return nil
+}
+
+func runView(args []string) error {
+	fs := newFlagSet("view")
+	addViewerFlags(fs)
+	treeName := fs.String("tree", "", "name of the tree displayed first")
+	var selectNode optionalUint64
+	fs.Var(&selectNode, "select", "`id` of the node selected at start")
+	layout := layoutFlag(fs)
+	filterExpr := fs.String("filter", "", "only show nodes matching the expression (and their ancestors), e.g. 'depth >= 3 && status == pruned-by-bound'")
+	noPrefetch := fs.Bool("no-prefetch", false, "same as -prefetch=false")
+	compareWith := fs.String("compare", "", "name of a tree compared with the displayed one")
+	matchKey := matchFlag(fs)
+	colorBy := fs.String("color-by", "", "data `key` coloring the nodes: gradient for numbers, categories otherwise")
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *noPrefetch {
+		config.Prefetch = false
+	}
+	if err := setLayout(*layout); err != nil {
+		return err
+	}
+
+	input := Input{TreeName: *treeName, SelectNode: selectNode.value, CompareWith: *compareWith, MatchKey: *matchKey, ColorBy: *colorBy}
+	if *filterExpr != "" {
+		input.Filter, err = parseFilter(*filterExpr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(files) == 1 && isSessionFile(files[0]) {
+		input.Session, err = readSession(files[0])
+		if err != nil {
+			return err
+		}
+		files = input.Session.Files
+	}
+	input.Files = files
+
+	for _, f := range files {
+		lastOpenFile = f
+		entries, err := indexSearchTrees(f)
+		if err != nil {
+			return err
+		}
+		input.Trees = append(input.Trees, entries...)
+	}
+
+	for _, name := range []string{input.TreeName, input.CompareWith} {
+		if name != "" && len(input.Trees) > 0 && !slices.ContainsFunc(input.Trees, func(e treeEntry) bool { return e.name == name }) {
+			return fmt.Errorf("no tree named %q", name)
+		}
+	}
+
+	runVisu(input)
+	return nil
+}
+
+func matchFlag(fs *flag.FlagSet) *string {
+	return fs.String("match", "", "data `key` of the branching decisions, matching compared nodes by their path of decisions instead of their id")
+}
+
+func runCompare(args []string) error {
+	fs := newFlagSet("compare")
+	addViewerFlags(fs)
+	matchKey := matchFlag(fs)
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 2 {
+		fs.Usage()
+		return fmt.Errorf("compare needs exactly two files")
+	}
+
+	a, err := indexSearchTrees(files[0])
+	if err != nil {
+		return err
+	}
+	b, err := indexSearchTrees(files[1])
+	if err != nil {
+		return err
+	}
+	if len(a) != 1 || len(b) != 1 {
+		return fmt.Errorf("compare needs files with a single tree, use view --tree A --compare B for archives")
+	}
+	if a[0].name == b[0].name {
+		a[0].name += " (A)"
+		b[0].name += " (B)"
+	}
+
+	lastOpenFile = files[0]
+	runVisu(Input{
+		Trees:       []treeEntry{a[0], b[0]},
+		Files:       files,
+		TreeName:    a[0].name,
+		CompareWith: b[0].name,
+		MatchKey:    *matchKey,
+	})
+	return nil
+}
+
+// loadOneTree loads the tree of a file, or the tree named treeName when the file contains several.
+func loadOneTree(filename string, treeName string) (systems.SearchTree, error) {
+	entries, err := indexSearchTrees(filename)
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	if len(entries) == 0 {
+		return systems.SearchTree{}, fmt.Errorf("no tree in %s", filename)
+	}
+	if treeName == "" {
+		if len(entries) > 1 {
+			return systems.SearchTree{}, fmt.Errorf("%s contains %d trees, choose one with -tree", filename, len(entries))
+		}
+		return entries[0].load()
+	}
+	for _, e := range entries {
+		if e.name == treeName {
+			return e.load()
+		}
+	}
+	return systems.SearchTree{}, fmt.Errorf("no tree named %q", treeName)
+}
+
+func runRender(args []string) error {
+	fs := newFlagSet("render")
+	output := fs.String("o", "tree.png", "output file, its extension giving the format: png, jpg, svg, pdf...")
+	treeName := fs.String("tree", "", "name of the tree to draw, for files containing several trees")
+	var root optionalUint64
+	fs.Var(&root, "root", "`id` of the root of the drawn subtree")
+	maxDepth := fs.Int("max-depth", -1, "only draw nodes up to this depth below the root, -1 for no limit")
+	colorBy := fs.String("color-by", "", "data `key` coloring the nodes: gradient for numbers, categories otherwise")
+	page := fs.String("page", "", "split the drawing in pages: a4, a3, letter..., WIDTHxHEIGHT in mm, with an optional -landscape suffix")
+	scale := fs.Float64("scale", 0.25, "millimeters per node unit, nodes being 100 units wide")
+	dpmm := fs.Float64("dpmm", 8, "dots per millimeter of raster images")
+	layout := layoutFlag(fs)
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return fmt.Errorf("render needs exactly one file")
+	}
+	if err := setLayout(*layout); err != nil {
+		return err
+	}
+
+	opts := render.Options{
+		Root:     root.value,
+		MaxDepth: *maxDepth,
+		ColorBy:  *colorBy,
+		Scale:    *scale,
+		Layout:   layoutCoordinates,
+		FontData: Must(f.ReadFile("data/Roboto.ttf")),
+	}
+	if *page != "" {
+		opts.PageWidth, opts.PageHeight, err = render.PageSize(*page)
+		if err != nil {
+			return err
+		}
+	}
+
+	tree, err := loadOneTree(files[0], *treeName)
+	if err != nil {
+		return err
+	}
+	pages, err := render.Draw(tree, opts)
+	if err != nil {
+		return err
+	}
+	return render.Write(*output, pages, *dpmm)
+}
+
+func runStats(args []string) error {
+	fs := newFlagSet("stats")
+	jsonOutput := fs.Bool("json", false, "print the statistics as json")
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("stats needs at least one file")
+	}
+
+	stats, err := loadTreeStats(files)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	return writeStatsTable(os.Stdout, stats)
+}
+
+func runDiffStats(args []string) error {
+	fs := newFlagSet("diff-stats")
+	jsonOutput := fs.Bool("json", false, "print the comparison as json")
+	maxNodeIncrease := fs.String("max-node-increase", "", "fail when the number of nodes grows more than this, e.g. 10% or 500")
+	maxLeafIncrease := fs.String("max-leaf-increase", "", "fail when the number of leaves grows more than this")
+	maxDepthIncrease := fs.String("max-depth-increase", "", "fail when the maximal depth grows more than this")
+	var metrics, thresholdExprs stringList
+	fs.Var(&metrics, "metric", "data `KEY[:STAT]` to compare, STAT being one of "+strings.Join(dataStats, ", ")+" (mean by default); can be repeated")
+	fs.Var(&thresholdExprs, "threshold", "fail when a metric grows more than a limit: `METRIC=LIMIT`, e.g. obj:max=5%; can be repeated")
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 2 {
+		fs.Usage()
+		return fmt.Errorf("diff-stats needs exactly two files")
+	}
+
+	thresholds := make([]threshold, 0)
+	for _, l := range []struct{ metric, limit string }{
+		{"nodes", *maxNodeIncrease},
+		{"leaves", *maxLeafIncrease},
+		{"max_depth", *maxDepthIncrease},
+	} {
+		metric, limit := l.metric, l.limit
+		if limit == "" {
+			continue
+		}
+		t, err := newThreshold(metric, limit)
+		if err != nil {
+			return err
+		}
+		thresholds = append(thresholds, t)
+	}
+	for _, expr := range thresholdExprs {
+		t, err := parseThreshold(expr)
+		if err != nil {
+			return err
+		}
+		thresholds = append(thresholds, t)
+	}
+
+	compared := slices.Clone(defaultDiffMetrics)
+	for _, m := range metrics {
+		if !slices.Contains(compared, m) {
+			compared = append(compared, m)
+		}
+	}
+	for _, t := range thresholds {
+		if !slices.Contains(compared, t.metric) {
+			compared = append(compared, t.metric)
+		}
+	}
+	for _, m := range compared {
+		if err := checkMetric(m); err != nil {
+			return err
+		}
+	}
+
+	oldStats, err := loadTreeStats(files[:1])
+	if err != nil {
+		return err
+	}
+	newStats, err := loadTreeStats(files[1:])
+	if err != nil {
+		return err
+	}
+	diff, err := diffStats(oldStats, newStats, compared, thresholds)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(diff)
+	} else {
+		err = writeStatsDiff(os.Stdout, diff)
+	}
+	if err != nil {
+		return err
+	}
+
+	if diff.Exceeded {
+		return errThresholdsExceeded
+	}
+	return nil
+}
+
+func runValidate(args []string) error {
+	fs := newFlagSet("validate")
+	jsonOutput := fs.Bool("json", false, "print the invalid nodes as json")
+
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return fmt.Errorf("validate needs at least one file")
+	}
+
+	validations, err := loadTreeValidations(files)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(validations)
+	} else {
+		err = writeValidations(os.Stdout, validations)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, v := range validations {
+		if len(v.Invalid) > 0 {
+			return errInvalidNodes
+		}
+	}
+	return nil
+}
+
+func runGenerate(args []string) error {
+	fs := newFlagSet("generate")
+	nbNodes := fs.Int("nodes", 1000, "number of nodes")
+	kind := fs.String("kind", "large", "shape of the tree: large or deep")
+	output := fs.String("o", "-", "output file, - for stdout")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	var tree Tree
+	switch *kind {
+	case "large":
+		tree = GenerateLargeInput(*nbNodes)
+	case "deep":
+		tree = GenerateDeepInput(*nbNodes)
+	default:
+		return fmt.Errorf("unknown kind %q", *kind)
+	}
+
+	return writeOutput(*output, func(w io.Writer) error {
+		// encoding/json compacts the output of orderedmap, jsoniter does not
+		return json.NewEncoder(w).Encode(tree)
+	})
+}
+
+// writeOutput writes to the file, or to stdout for "-".
+func writeOutput(filename string, write func(w io.Writer) error) error {
+	if filename == stdinFilename {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
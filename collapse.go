@@ -0,0 +1,50 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// toggleCollapse hides the subtree of the node, or shows it again.
+func (e *treeEngine) toggleCollapse(id uint64) {
+	if e.collapsed[id] {
+		delete(e.collapsed, id)
+	} else {
+		if e.collapsed == nil {
+			e.collapsed = make(map[uint64]bool)
+		}
+		e.collapsed[id] = true
+	}
+	e.updateVisibility()
+}
+
+// handleCollapseKey collapses the subtree of the selected node when "c" is pressed.
+func (e *treeEngine) handleCollapseKey() {
+	if e.mouseCaptured || e.findMode || !rl.IsKeyPressed(rl.KeyC) {
+		return
+	}
+	if id, ok := e.ecosystem.sys.SelectedNode(); ok {
+		e.toggleCollapse(id)
+	}
+}
+
+// withoutCollapsed removes the descendants of the collapsed nodes from the tree.
+func withoutCollapsed(tree *GraphView, collapsed map[uint64]bool) *GraphView {
+	if len(collapsed) == 0 {
+		return tree
+	}
+
+	hidden := make(map[uint64]bool)
+	for i, n := range tree.Nodes {
+		if !collapsed[n.Id] {
+			continue
+		}
+		stack := tree.ChildrenIndices(i)
+		for len(stack) > 0 {
+			child := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !hidden[tree.Nodes[child].Id] {
+				hidden[tree.Nodes[child].Id] = true
+				stack = append(stack, tree.ChildrenIndices(child)...)
+			}
+		}
+	}
+	return tree.Subgraph(func(n *DisplayableNode) bool { return !hidden[n.Id] })
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/graph"
+	"github.com/gverger/optimview/systems"
+	"github.com/iancoleman/orderedmap"
+)
+
+type nodeDiff int
+
+const (
+	sameNode nodeDiff = iota
+	changedNode
+	onlyInA
+	onlyInB
+)
+
+var nodeDiffColors = map[nodeDiff]color.RGBA{
+	changedNode: systems.HexToRGBA(0xFFD54F),
+	onlyInA:     systems.HexToRGBA(0x9ECAE1),
+	onlyInB:     systems.HexToRGBA(0xFDAE6B),
+}
+
+var nodeDiffLabels = map[nodeDiff]string{
+	changedNode: "different data",
+	onlyInA:     "only in A",
+	onlyInB:     "only in B",
+}
+
+// treeComparison merges two trees: matched nodes appear once, with the structure of tree A, and
+// the nodes of B without match are added under their parents.
+type treeComparison struct {
+	nameA    string
+	nameB    string
+	matchKey string
+
+	merged systems.SearchTree
+	diffs  map[uint64]nodeDiff
+	// Nodes of both trees by merged id, missing when the node is not in the tree
+	nodesA map[uint64]*DisplayableNode
+	nodesB map[uint64]*DisplayableNode
+}
+
+// matchKeys returns the key used to match each node of the tree: its id, or the path of values of
+// the match key from the root, like /1/3. Nodes are returned parents first.
+func matchKeys(tree *GraphView, matchKey string) ([]int, []string) {
+	parents := tree.ParentIndices()
+	order := make([]int, 0, len(tree.Nodes))
+	for i, p := range parents {
+		if p == -1 {
+			order = append(order, i)
+		}
+	}
+	for k := 0; k < len(order); k++ {
+		order = append(order, tree.ChildrenIndices(order[k])...)
+	}
+
+	keys := make([]string, len(tree.Nodes))
+	for _, i := range order {
+		n := tree.Nodes[i]
+		if matchKey == "" || matchKey == "id" {
+			keys[i] = strconv.FormatUint(n.Id, 10)
+			continue
+		}
+		step := ""
+		if v, ok := n.Value(matchKey); ok {
+			step = fmt.Sprint(v)
+		}
+		if parents[i] != -1 {
+			keys[i] = keys[parents[i]]
+		}
+		keys[i] += "/" + step
+	}
+	return order, keys
+}
+
+func compareTrees(nameA string, a systems.SearchTree, nameB string, b systems.SearchTree, matchKey string) *treeComparison {
+	c := &treeComparison{
+		nameA:    nameA,
+		nameB:    nameB,
+		matchKey: matchKey,
+		diffs:    make(map[uint64]nodeDiff),
+		nodesA:   make(map[uint64]*DisplayableNode),
+		nodesB:   make(map[uint64]*DisplayableNode),
+	}
+
+	merged := graph.NewGraph(a.Tree.NodeID)
+	// Nodes of A with the same key, to match them in turn when keys are not unique
+	unmatched := make(map[string][]uint64)
+	nextId := uint64(0)
+	orderA, keysA := matchKeys(a.Tree, matchKey)
+	for _, i := range orderA {
+		n := a.Tree.Nodes[i]
+		node := *n
+		merged.AddNode(&node)
+		c.nodesA[n.Id] = n
+		c.diffs[n.Id] = onlyInA
+		unmatched[keysA[i]] = append(unmatched[keysA[i]], n.Id)
+		nextId = max(nextId, n.Id+1)
+	}
+	for i, dst := range a.Tree.Edges {
+		for j := range dst {
+			merged.AddEdgeId(a.Tree.Nodes[i].Id, a.Tree.Nodes[j].Id)
+		}
+	}
+
+	// Shapes of B come after the ones of A
+	shapeOffset := len(a.Shapes)
+	mergedIds := make([]uint64, len(b.Tree.Nodes))
+	parentsB := b.Tree.ParentIndices()
+	orderB, keysB := matchKeys(b.Tree, matchKey)
+	for _, i := range orderB {
+		n := b.Tree.Nodes[i]
+		if ids := unmatched[keysB[i]]; len(ids) > 0 {
+			mergedIds[i] = ids[0]
+			unmatched[keysB[i]] = ids[1:]
+			c.nodesB[ids[0]] = n
+			c.diffs[ids[0]] = sameNode
+			if !sameData(c.nodesA[ids[0]].Data, n.Data) {
+				c.diffs[ids[0]] = changedNode
+			}
+			continue
+		}
+
+		id := n.Id
+		if merged.HasNode(&DisplayableNode{Id: id}) {
+			id = nextId
+		}
+		nextId = max(nextId, id+1)
+		mergedIds[i] = id
+
+		node := *n
+		node.Id = id
+		node.Transform = slices.Clone(n.Transform)
+		for t := range node.Transform {
+			node.Transform[t].Id += shapeOffset
+		}
+		merged.AddNode(&node)
+		if parentsB[i] != -1 {
+			merged.AddEdgeId(mergedIds[parentsB[i]], id)
+		}
+		c.nodesB[id] = n
+		c.diffs[id] = onlyInB
+	}
+
+	c.merged = systems.SearchTree{
+		Tree:   merged,
+		Shapes: slices.Concat(a.Shapes, b.Shapes),
+	}
+	return c
+}
+
+// sameData compares the values of the data as displayed.
+func sameData(a, b *orderedmap.OrderedMap) bool {
+	keysA, keysB := dataKeys(a), dataKeys(b)
+	if len(keysA) != len(keysB) {
+		return false
+	}
+	for _, k := range keysA {
+		va, _ := a.Get(k)
+		vb, ok := b.Get(k)
+		if !ok || fmt.Sprint(va) != fmt.Sprint(vb) {
+			return false
+		}
+	}
+	return true
+}
+
+func dataKeys(data *orderedmap.OrderedMap) []string {
+	if data == nil {
+		return nil
+	}
+	return data.Keys()
+}
+
+func (c *treeComparison) name() string {
+	return c.nameA + " vs " + c.nameB
+}
+
+func (c *treeComparison) colors() map[uint64]color.RGBA {
+	colors := make(map[uint64]color.RGBA, len(c.diffs))
+	for id, d := range c.diffs {
+		if col, ok := nodeDiffColors[d]; ok {
+			colors[id] = col
+		}
+	}
+	return colors
+}
+
+// displayedTree is the tree of the ecosystem: the current tree, or the merged tree when comparing.
+func (e *treeEngine) displayedTree() systems.SearchTree {
+	if e.comparison != nil {
+		return e.comparison.merged
+	}
+	return e.app.tree()
+}
+
+func (e *treeEngine) displayedTreeName() string {
+	if e.comparison != nil {
+		return e.comparison.name()
+	}
+	return e.app.treeNames[e.app.currentTree]
+}
+
+// loadEcosystem builds the ecosystem of the current tree, merged with the compared tree in compare
+// mode.
+func (e *treeEngine) loadEcosystem() ecosystem {
+	if !e.compareMode {
+		e.comparison = nil
+		return e.app.loadTree(e.font)
+	}
+
+	e.compareTree = min(e.compareTree, int32(len(e.app.treeNames)-1))
+	e.comparison = compareTrees(
+		e.app.treeNames[e.app.currentTree], e.app.tree(),
+		e.app.treeNames[e.compareTree], e.app.treeAt(e.compareTree),
+		strings.TrimSpace(e.compareKey))
+	return newEcosystem(e.comparison.merged, e.font)
+}
+
+// applyColors colors the nodes by their differences when comparing, or by the value of the color
+// key.
+func (e *treeEngine) applyColors() {
+	if e.comparison != nil {
+		e.ecosystem.sys.SetNodeColors(e.comparison.colors())
+		return
+	}
+	colors := make(map[uint64]color.RGBA)
+	if key := strings.TrimSpace(e.colorBy); key != "" {
+		colors = systems.DataColors(e.displayedTree().Tree.Nodes, key)
+	}
+	e.ecosystem.sys.SetNodeColors(colors)
+}
+
+// drawCompareControls draws the controls of the compare mode in the top bar, from offsetX. It
+// returns the area of the controls.
+func (e *treeEngine) drawCompareControls(offsetX float32, height float32) rl.Rectangle {
+	area := rl.NewRectangle(offsetX, 2, 0, height)
+
+	compareSize := navButton("Compare")
+	compareRec := rl.NewRectangle(offsetX, 2, compareSize.X, height)
+	if compareMode := gui.Toggle(compareRec, "Compare", e.compareMode); compareMode != e.compareMode {
+		e.compareMode = compareMode
+		if e.compareMode {
+			e.splitMode = false
+		}
+		e.switchTree()
+	}
+	offsetX += compareRec.Width + 10
+	area.Width = offsetX - area.X
+
+	if !e.compareMode {
+		return area
+	}
+
+	withSize := navButton("with")
+	gui.Label(rl.NewRectangle(offsetX, 2, withSize.X, height), "with")
+	offsetX += withSize.X
+
+	dropDownWidth := float32(0)
+	for _, name := range e.app.treeNames {
+		dropDownWidth = max(dropDownWidth, navButton(name).X+20)
+	}
+	dropDownRec := rl.NewRectangle(offsetX, 2, dropDownWidth, height)
+	at := e.compareTree
+	if gui.DropdownBox(dropDownRec, strings.Join(e.app.treeNames, ";"), &e.compareTree, e.compareEditMode) {
+		if e.compareEditMode && at != e.compareTree {
+			e.switchTree()
+		}
+		e.compareEditMode = !e.compareEditMode
+	}
+	offsetX += dropDownWidth + 10
+
+	matchSize := navButton("match on")
+	gui.Label(rl.NewRectangle(offsetX, 2, matchSize.X, height), "match on")
+	offsetX += matchSize.X
+
+	keyRec := rl.NewRectangle(offsetX, 2, 100, height)
+	if gui.TextBox(keyRec, &e.compareKey, 32, e.compareKeyEdit) {
+		if e.compareKeyEdit {
+			e.switchTree()
+		}
+		e.compareKeyEdit = !e.compareKeyEdit
+	}
+	offsetX += keyRec.Width + 10
+
+	area.Width = offsetX - area.X
+	if e.compareEditMode {
+		// the list of the open dropdown
+		area.Height += float32(len(e.app.treeNames)) * (height + 2)
+	}
+	return area
+}
+
+const compareCellWidth = 160
+
+// drawComparePanel draws the legend of the colors, and the data of the selected node in both trees,
+// at (x, y). It returns the area of the panel.
+func (e *treeEngine) drawComparePanel(x, y float32) rl.Rectangle {
+	if e.comparison == nil {
+		return rl.Rectangle{}
+	}
+	c := e.comparison
+
+	type row struct {
+		key, a, b string
+	}
+	rows := make([]row, 0)
+	id, selected := e.ecosystem.sys.SelectedNode()
+	if selected {
+		a, b := c.nodesA[id], c.nodesB[id]
+		keys := dataKeys(nodeData(a))
+		for _, k := range dataKeys(nodeData(b)) {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+		rows = append(rows, row{key: "id", a: nodeIdText(a), b: nodeIdText(b)})
+		for _, k := range keys {
+			rows = append(rows, row{key: k, a: nodeValueText(a, k), b: nodeValueText(b, k)})
+		}
+	}
+
+	lineHeight := float32(20)
+	width := float32(3*compareCellWidth + 20)
+	height := 24 + 10 + lineHeight*float32(len(nodeDiffLabels)+1+len(rows)) + 10
+	panelRec := rl.NewRectangle(x, y, width, height)
+	gui.Panel(panelRec, fmt.Sprintf("A: %s, B: %s", c.nameA, c.nameB))
+
+	x = panelRec.X + 10
+	y = panelRec.Y + 24 + 10
+	for _, d := range []nodeDiff{onlyInA, onlyInB, changedNode} {
+		rl.DrawRectangleRec(rl.NewRectangle(x, y+2, 14, 14), nodeDiffColors[d])
+		rl.DrawTextEx(e.font, nodeDiffLabels[d], rl.NewVector2(x+20, y), 16, 0, rl.Black)
+		y += lineHeight
+	}
+	y += lineHeight
+
+	for _, r := range rows {
+		color := rl.Black
+		if r.a != r.b {
+			color = rl.Maroon
+		}
+		for col, text := range []string{r.key, r.a, r.b} {
+			rl.DrawTextEx(e.font, truncateText(text, 22), rl.NewVector2(x+float32(col)*compareCellWidth, y), 16, 0, color)
+		}
+		y += lineHeight
+	}
+
+	return panelRec
+}
+
+func nodeData(n *DisplayableNode) *orderedmap.OrderedMap {
+	if n == nil {
+		return nil
+	}
+	return n.Data
+}
+
+func nodeIdText(n *DisplayableNode) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.FormatUint(n.Id, 10)
+}
+
+func nodeValueText(n *DisplayableNode, key string) string {
+	if n == nil {
+		return "-"
+	}
+	v, ok := n.Value(key)
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprint(v)
+}
+
+// truncateText shortens s to n characters, counted in runes so that no character is cut.
+func truncateText(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/gverger/optimview/graph"
+	"github.com/gverger/optimview/systems"
+	"github.com/iancoleman/orderedmap"
+)
+
+// testNode is a node of a tree built by testTree, the root having no parent (-1).
+type testNode struct {
+	id     uint64
+	parent int64
+	data   map[string]any
+}
+
+func testTree(nodes ...testNode) systems.SearchTree {
+	tree := graph.NewGraph[*DisplayableNode, uint64](func(n *DisplayableNode) uint64 { return n.Id })
+	for _, n := range nodes {
+		data := orderedmap.New()
+		for k, v := range n.data {
+			data.Set(k, v)
+		}
+		tree.AddNode(&DisplayableNode{Id: n.id, Data: data})
+	}
+	for _, n := range nodes {
+		if n.parent != -1 {
+			tree.AddEdgeId(uint64(n.parent), n.id)
+		}
+	}
+	return systems.SearchTree{Tree: tree, Shapes: make([]systems.ShapeDefinition, 0)}
+}
+
+// parentIds returns the parent id of every node of the tree, -1 for the roots.
+func parentIds(tree *GraphView) map[uint64]int64 {
+	parents := make(map[uint64]int64, len(tree.Nodes))
+	for i, p := range tree.ParentIndices() {
+		parents[tree.Nodes[i].Id] = -1
+		if p != -1 {
+			parents[tree.Nodes[i].Id] = int64(tree.Nodes[p].Id)
+		}
+	}
+	return parents
+}
+
+func TestMatchKeys(t *testing.T) {
+	tree := testTree(
+		testNode{id: 0, parent: -1},
+		testNode{id: 1, parent: 0, data: map[string]any{"x": "a"}},
+		testNode{id: 2, parent: 1, data: map[string]any{"x": 3}},
+		testNode{id: 3, parent: 0},
+	).Tree
+
+	tests := []struct {
+		matchKey string
+		want     map[uint64]string
+	}{
+		{"", map[uint64]string{0: "0", 1: "1", 2: "2", 3: "3"}},
+		{"id", map[uint64]string{0: "0", 1: "1", 2: "2", 3: "3"}},
+		{"x", map[uint64]string{0: "/", 1: "//a", 2: "//a/3", 3: "//"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.matchKey, func(t *testing.T) {
+			order, keys := matchKeys(tree, tt.matchKey)
+			got := make(map[uint64]string)
+			for i, k := range keys {
+				got[tree.Nodes[i].Id] = k
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("matchKeys keys = %v, want %v", got, tt.want)
+			}
+
+			// Parents first
+			seen := make(map[int]bool)
+			parents := tree.ParentIndices()
+			for _, i := range order {
+				if parents[i] != -1 && !seen[parents[i]] {
+					t.Errorf("matchKeys order %v has node %d before its parent", order, i)
+				}
+				seen[i] = true
+			}
+			if len(order) != len(tree.Nodes) {
+				t.Errorf("matchKeys order %v, want the %d nodes", order, len(tree.Nodes))
+			}
+		})
+	}
+}
+
+func TestCompareTrees(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []testNode
+		matchKey string
+		want     map[uint64]nodeDiff
+		// Parent of every merged node
+		wantParents map[uint64]int64
+	}{
+		{
+			name:        "same trees",
+			a:           []testNode{{0, -1, nil}, {1, 0, nil}, {2, 0, nil}},
+			b:           []testNode{{0, -1, nil}, {1, 0, nil}, {2, 0, nil}},
+			want:        map[uint64]nodeDiff{0: sameNode, 1: sameNode, 2: sameNode},
+			wantParents: map[uint64]int64{0: -1, 1: 0, 2: 0},
+		},
+		{
+			name:        "by id, only in A or in B",
+			a:           []testNode{{0, -1, nil}, {1, 0, nil}, {2, 0, nil}},
+			b:           []testNode{{0, -1, nil}, {1, 0, nil}, {3, 1, nil}},
+			want:        map[uint64]nodeDiff{0: sameNode, 1: sameNode, 2: onlyInA, 3: onlyInB},
+			wantParents: map[uint64]int64{0: -1, 1: 0, 2: 0, 3: 1},
+		},
+		{
+			name:        "changed data",
+			a:           []testNode{{0, -1, nil}, {1, 0, map[string]any{"obj": 1}}, {2, 0, map[string]any{"obj": 2}}},
+			b:           []testNode{{0, -1, nil}, {1, 0, map[string]any{"obj": 1}}, {2, 0, map[string]any{"obj": 3}}},
+			want:        map[uint64]nodeDiff{0: sameNode, 1: sameNode, 2: changedNode},
+			wantParents: map[uint64]int64{0: -1, 1: 0, 2: 0},
+		},
+		{
+			name:        "added data key",
+			a:           []testNode{{0, -1, nil}},
+			b:           []testNode{{0, -1, map[string]any{"obj": 1}}},
+			want:        map[uint64]nodeDiff{0: changedNode},
+			wantParents: map[uint64]int64{0: -1},
+		},
+		{
+			name: "by data key",
+			a: []testNode{
+				{0, -1, nil},
+				{1, 0, map[string]any{"x": "a"}},
+				{2, 0, map[string]any{"x": "b"}},
+			},
+			b: []testNode{
+				{0, -1, nil},
+				{5, 0, map[string]any{"x": "b"}},
+				{6, 0, map[string]any{"x": "a"}},
+				{7, 5, map[string]any{"x": "c"}},
+			},
+			matchKey:    "x",
+			want:        map[uint64]nodeDiff{0: sameNode, 1: sameNode, 2: sameNode, 7: onlyInB},
+			wantParents: map[uint64]int64{0: -1, 1: 0, 2: 0, 7: 2},
+		},
+		{
+			name:        "by data key, id of B taken in A",
+			a:           []testNode{{0, -1, nil}, {1, 0, map[string]any{"x": "a"}}},
+			b:           []testNode{{0, -1, nil}, {1, 0, map[string]any{"x": "b"}}},
+			matchKey:    "x",
+			want:        map[uint64]nodeDiff{0: sameNode, 1: onlyInA, 2: onlyInB},
+			wantParents: map[uint64]int64{0: -1, 1: 0, 2: 0},
+		},
+		{
+			name:        "by data key, same values on another path",
+			a:           []testNode{{0, -1, nil}, {1, 0, map[string]any{"x": "a"}}, {2, 1, map[string]any{"x": "b"}}},
+			b:           []testNode{{0, -1, nil}, {1, 0, map[string]any{"x": "b"}}, {2, 1, map[string]any{"x": "a"}}},
+			matchKey:    "x",
+			want:        map[uint64]nodeDiff{0: sameNode, 1: onlyInA, 2: onlyInA, 3: onlyInB, 4: onlyInB},
+			wantParents: map[uint64]int64{0: -1, 1: 0, 2: 1, 3: 0, 4: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := compareTrees("A", testTree(tt.a...), "B", testTree(tt.b...), tt.matchKey)
+			if !maps.Equal(c.diffs, tt.want) {
+				t.Errorf("compareTrees diffs = %v, want %v", c.diffs, tt.want)
+			}
+			if got := parentIds(c.merged.Tree); !maps.Equal(got, tt.wantParents) {
+				t.Errorf("compareTrees parents = %v, want %v", got, tt.wantParents)
+			}
+			for id, d := range c.diffs {
+				if _, inA := c.nodesA[id]; inA != (d != onlyInB) {
+					t.Errorf("node %d (%v) in A = %v", id, d, inA)
+				}
+				if _, inB := c.nodesB[id]; inB != (d != onlyInA) {
+					t.Errorf("node %d (%v) in B = %v", id, d, inB)
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly 10", 10, "exactly 10"},
+		{"longer than 10", 10, "longer ..."},
+		{"décision é", 10, "décision é"},
+		{"décisions à prendre", 10, "décisio..."},
+		{"→→→→→→", 5, "→→..."},
+	}
+	for _, tt := range tests {
+		if got := truncateText(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
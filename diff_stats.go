@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// errThresholdsExceeded is returned by diff-stats when a metric increased more than allowed.
+var errThresholdsExceeded = errors.New("thresholds exceeded")
+
+// Metrics compared for every tree. Data metrics are named KEY:STAT, like obj:mean.
+var defaultDiffMetrics = []string{"nodes", "leaves", "max_depth", "mean_depth", "mean_branching"}
+
+var dataStats = []string{"count", "min", "max", "mean", "p10", "p25", "median", "p75", "p90"}
+
+// metric returns the value of a metric of the tree statistics.
+func (s treeStats) metric(name string) (float64, bool) {
+	switch name {
+	case "nodes":
+		return float64(s.Nodes), true
+	case "leaves":
+		return float64(s.Leaves), true
+	case "max_depth":
+		return float64(s.MaxDepth), true
+	case "mean_depth":
+		return s.MeanDepth, true
+	case "mean_branching":
+		return s.MeanBranching, true
+	}
+
+	key, stat, found := strings.Cut(name, ":")
+	if !found {
+		stat = "mean"
+	}
+	i := slices.IndexFunc(s.Data, func(d dataSummary) bool { return d.Key == key })
+	if i == -1 {
+		return 0, false
+	}
+	d := s.Data[i]
+	switch stat {
+	case "count":
+		return float64(d.Count), true
+	case "min":
+		return d.Min, true
+	case "max":
+		return d.Max, true
+	case "mean":
+		return d.Mean, true
+	case "p10":
+		return d.P10, true
+	case "p25":
+		return d.P25, true
+	case "median":
+		return d.Median, true
+	case "p75":
+		return d.P75, true
+	case "p90":
+		return d.P90, true
+	}
+	return 0, false
+}
+
+// checkMetric fails when the statistic of a data metric, after the colon, is not one of dataStats.
+func checkMetric(metric string) error {
+	if _, stat, found := strings.Cut(metric, ":"); found && !slices.Contains(dataStats, stat) {
+		return fmt.Errorf("unknown statistic %q in %s", stat, metric)
+	}
+	return nil
+}
+
+// threshold is the maximal increase of a metric, relative (in percent) or absolute.
+type threshold struct {
+	metric   string
+	limit    float64
+	relative bool
+}
+
+// parseThreshold parses METRIC=LIMIT, like nodes=10% or max_depth=2.
+func parseThreshold(s string) (threshold, error) {
+	metric, limit, found := strings.Cut(s, "=")
+	if !found {
+		return threshold{}, fmt.Errorf("invalid threshold %q, expecting METRIC=LIMIT", s)
+	}
+	return newThreshold(strings.TrimSpace(metric), limit)
+}
+
+func newThreshold(metric string, limit string) (threshold, error) {
+	t := threshold{metric: metric}
+	limit = strings.TrimSpace(limit)
+	if strings.HasSuffix(limit, "%") {
+		t.relative = true
+		limit = strings.TrimSuffix(limit, "%")
+	}
+	value, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return threshold{}, fmt.Errorf("invalid limit %q for %s", limit, metric)
+	}
+	t.limit = value
+	return t, nil
+}
+
+func (t threshold) String() string {
+	if t.relative {
+		return fmt.Sprintf("+%g%%", t.limit)
+	}
+	return fmt.Sprintf("+%g", t.limit)
+}
+
+func (t threshold) exceeded(old, new float64) bool {
+	if !t.relative {
+		return new-old > t.limit
+	}
+	if old == 0 {
+		return new > 0
+	}
+	return (new-old)/math.Abs(old)*100 > t.limit
+}
+
+type metricDiff struct {
+	Metric string `json:"metric"`
+	// Null when the tree has no such data key
+	Old *float64 `json:"old"`
+	New *float64 `json:"new"`
+	// Change in percent, null when the old value is 0
+	RelativeChange *float64 `json:"relative_change"`
+	Threshold      string   `json:"threshold,omitempty"`
+	Exceeded       bool     `json:"exceeded"`
+}
+
+type treeDiff struct {
+	Name    string       `json:"name"`
+	Metrics []metricDiff `json:"metrics"`
+}
+
+type statsDiff struct {
+	Trees    []treeDiff `json:"trees"`
+	Exceeded bool       `json:"exceeded"`
+}
+
+// diffStats compares the statistics of trees with the same name. When both sides have a single
+// tree, they are compared whatever their names. So that a renamed tree or a wrong file cannot pass
+// unnoticed, it fails when no tree is compared, when a tree is only on one side, when a metric with a
+// threshold is only on one side, and when a threshold names a metric that none of the compared trees
+// has, most likely a typo.
+func diffStats(old, new []treeStats, metrics []string, thresholds []threshold) (statsDiff, error) {
+	diff := statsDiff{Trees: make([]treeDiff, 0)}
+	// Metrics of at least one of the compared trees
+	known := make(map[string]bool)
+
+	if len(old) == 1 && len(new) == 1 && old[0].Name != new[0].Name {
+		name := old[0].Name + " -> " + new[0].Name
+		old = []treeStats{old[0]}
+		new = []treeStats{new[0]}
+		old[0].Name = name
+		new[0].Name = name
+	}
+
+	for _, o := range old {
+		i := slices.IndexFunc(new, func(s treeStats) bool { return s.Name == o.Name })
+		if i == -1 {
+			return statsDiff{}, fmt.Errorf("tree %q only in the old file", o.Name)
+		}
+
+		t := treeDiff{Name: o.Name, Metrics: make([]metricDiff, 0, len(metrics))}
+		for _, metric := range metrics {
+			oldValue, okOld := o.metric(metric)
+			newValue, okNew := new[i].metric(metric)
+			if !okOld && !okNew {
+				continue
+			}
+			known[metric] = true
+			m := metricDiff{Metric: metric}
+			if okOld {
+				m.Old = &oldValue
+			}
+			if okNew {
+				m.New = &newValue
+			}
+			if okOld && okNew && oldValue != 0 {
+				change := (newValue - oldValue) / math.Abs(oldValue) * 100
+				m.RelativeChange = &change
+			}
+			for _, th := range thresholds {
+				if th.metric != metric {
+					continue
+				}
+				m.Threshold = th.String()
+				if okOld != okNew {
+					side := "old"
+					if okNew {
+						side = "new"
+					}
+					return statsDiff{}, fmt.Errorf("metric %q of tree %q only in the %s file", metric, o.Name, side)
+				}
+				if th.exceeded(oldValue, newValue) {
+					m.Exceeded = true
+					diff.Exceeded = true
+				}
+			}
+			t.Metrics = append(t.Metrics, m)
+		}
+		diff.Trees = append(diff.Trees, t)
+	}
+
+	for _, n := range new {
+		if !slices.ContainsFunc(old, func(s treeStats) bool { return s.Name == n.Name }) {
+			return statsDiff{}, fmt.Errorf("tree %q only in the new file", n.Name)
+		}
+	}
+	if len(diff.Trees) == 0 {
+		return statsDiff{}, errors.New("no tree to compare")
+	}
+
+	for _, th := range thresholds {
+		if !known[th.metric] {
+			return statsDiff{}, fmt.Errorf("unknown metric %q", th.metric)
+		}
+	}
+	return diff, nil
+}
+
+func writeStatsDiff(w io.Writer, diff statsDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "tree\tmetric\told\tnew\tchange\tthreshold\t")
+	for _, t := range diff.Trees {
+		for _, m := range t.Metrics {
+			change := "-"
+			if m.RelativeChange != nil {
+				change = fmt.Sprintf("%+.1f%%", *m.RelativeChange)
+			}
+			threshold := m.Threshold
+			if m.Exceeded {
+				threshold += " EXCEEDED"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", t.Name, m.Metric, formatMetric(m.Old), formatMetric(m.New), change, threshold)
+		}
+	}
+	return tw.Flush()
+}
+
+func formatMetric(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.4g", *v)
+}
@@ -0,0 +1,187 @@
+package main
+
+import "testing"
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    threshold
+		wantErr bool
+	}{
+		{expr: "nodes=10%", want: threshold{metric: "nodes", limit: 10, relative: true}},
+		{expr: " max_depth = 2 ", want: threshold{metric: "max_depth", limit: 2}},
+		{expr: "obj:max=0.5%", want: threshold{metric: "obj:max", limit: 0.5, relative: true}},
+		{expr: "nodes", wantErr: true},
+		{expr: "nodes=", wantErr: true},
+		{expr: "nodes=ten", wantErr: true},
+		{expr: "nodes=10%%", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseThreshold(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseThreshold(%q) error = %v, want error %v", tt.expr, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseThreshold(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMetric(t *testing.T) {
+	tests := []struct {
+		metric  string
+		wantErr bool
+	}{
+		{"nodes", false},
+		{"obj", false},
+		{"obj:max", false},
+		{"obj:p90", false},
+		{"obj:maximum", true},
+		{"obj:", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			if err := checkMetric(tt.metric); (err != nil) != tt.wantErr {
+				t.Errorf("checkMetric(%q) = %v, want error %v", tt.metric, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestThresholdExceeded(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    string
+		old, new float64
+		want     bool
+	}{
+		{"absolute, below", "5", 10, 14, false},
+		{"absolute, at the limit", "5", 10, 15, false},
+		{"absolute, above", "5", 10, 16, true},
+		{"absolute, decrease", "0", 10, 2, false},
+		{"relative, below", "10%", 100, 105, false},
+		{"relative, above", "10%", 100, 111, true},
+		{"relative, negative old value", "10%", -100, -85, true},
+		{"relative, from 0", "10%", 0, 1, true},
+		{"relative, still 0", "10%", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th, err := newThreshold("nodes", tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := th.exceeded(tt.old, tt.new); got != tt.want {
+				t.Errorf("%s exceeded(%v, %v) = %v, want %v", th, tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStatsThresholds(t *testing.T) {
+	old := []treeStats{{Name: "run", Nodes: 100, MaxDepth: 5, Data: []dataSummary{{Key: "obj", Count: 3, Max: 10}}}}
+	new := []treeStats{{Name: "run", Nodes: 120, MaxDepth: 5, Data: []dataSummary{{Key: "obj", Count: 3, Max: 10}}}}
+	metrics := func(thresholds []threshold) []string {
+		m := []string{"nodes", "max_depth"}
+		for _, th := range thresholds {
+			m = append(m, th.metric)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name         string
+		thresholds   []string
+		wantExceeded bool
+		wantErr      bool
+	}{
+		{name: "no threshold"},
+		{name: "within the limits", thresholds: []string{"nodes=20%", "max_depth=0", "obj:max=0"}},
+		{name: "exceeded", thresholds: []string{"nodes=10%"}, wantExceeded: true},
+		{name: "one of several exceeded", thresholds: []string{"max_depth=0", "nodes=19"}, wantExceeded: true},
+		{name: "unknown metric", thresholds: []string{"depht=5"}, wantErr: true},
+		{name: "unknown data key", thresholds: []string{"bound:max=5"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := make([]threshold, 0)
+			for _, expr := range tt.thresholds {
+				th, err := parseThreshold(expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				thresholds = append(thresholds, th)
+			}
+			diff, err := diffStats(old, new, metrics(thresholds), thresholds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("diffStats error = %v, want error %v", err, tt.wantErr)
+			}
+			if diff.Exceeded != tt.wantExceeded {
+				t.Errorf("diffStats exceeded = %v, want %v", diff.Exceeded, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func TestDiffStatsMatching(t *testing.T) {
+	run := func(name string, data ...dataSummary) treeStats {
+		return treeStats{Name: name, Nodes: 10, Data: data}
+	}
+	obj := dataSummary{Key: "obj", Count: 3, Max: 10}
+	objMax, err := parseThreshold("obj:max=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		old, new   []treeStats
+		thresholds []threshold
+		wantErr    string
+	}{
+		{name: "same names", old: []treeStats{run("a"), run("b")}, new: []treeStats{run("b"), run("a")}},
+		{name: "single trees renamed", old: []treeStats{run("a")}, new: []treeStats{run("b")}},
+		{name: "no tree", wantErr: "no tree to compare"},
+		{name: "only in old", old: []treeStats{run("a"), run("b")}, new: []treeStats{run("a")}, wantErr: `tree "b" only in the old file`},
+		{name: "only in new", old: []treeStats{run("a")}, new: []treeStats{run("a"), run("c")}, wantErr: `tree "c" only in the new file`},
+		{name: "no name in common", old: []treeStats{run("a"), run("b")}, new: []treeStats{run("c"), run("d")}, wantErr: `tree "a" only in the old file`},
+		{
+			name:       "threshold metric in both",
+			old:        []treeStats{run("a", obj)},
+			new:        []treeStats{run("a", obj)},
+			thresholds: []threshold{objMax},
+		},
+		{
+			name:       "threshold metric only in old",
+			old:        []treeStats{run("a", obj)},
+			new:        []treeStats{run("a")},
+			thresholds: []threshold{objMax},
+			wantErr:    `metric "obj:max" of tree "a" only in the old file`,
+		},
+		{
+			name:       "threshold metric only in new",
+			old:        []treeStats{run("a")},
+			new:        []treeStats{run("a", obj)},
+			thresholds: []threshold{objMax},
+			wantErr:    `metric "obj:max" of tree "a" only in the new file`,
+		},
+		{
+			name: "metric without threshold only in new",
+			old:  []treeStats{run("a")},
+			new:  []treeStats{run("a", obj)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := diffStats(tt.old, tt.new, []string{"nodes", "obj:max"}, tt.thresholds)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("diffStats error = %v, want none", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("diffStats error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
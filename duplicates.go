@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/systems"
+)
+
+// duplicateIndex groups the nodes of a tree with the same state: same placements, and same values of
+// the data keys.
+type duplicateIndex struct {
+	tree   *GraphView
+	keys   string
+	hashes map[uint64]uint64
+	groups [][]uint64
+	// Nodes of the group of each hash
+	byHash map[uint64][]uint64
+}
+
+func newDuplicateIndex(tree *GraphView, keys string) *duplicateIndex {
+	hashes, groups := systems.DuplicateGroups(tree, duplicateKeys(keys))
+	index := &duplicateIndex{tree: tree, keys: keys, hashes: hashes, groups: groups, byHash: make(map[uint64][]uint64)}
+	for _, g := range groups {
+		index.byHash[hashes[g[0]]] = g
+	}
+	return index
+}
+
+// duplicateKeys splits the comma separated data keys.
+func duplicateKeys(keys string) []string {
+	res := make([]string, 0)
+	for _, k := range strings.Split(keys, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// Duplicates returns the nodes with the same state as the node, itself included, or nil when the
+// state is unique.
+func (d *duplicateIndex) Duplicates(id uint64) []uint64 {
+	h, ok := d.hashes[id]
+	if !ok {
+		return nil
+	}
+	return d.byHash[h]
+}
+
+// currentDuplicates returns the duplicate index of the displayed tree, computed when first needed.
+// The keys being edited are used once validated.
+func (e *treeEngine) currentDuplicates() *duplicateIndex {
+	tree := e.displayedTree().Tree
+	if e.duplicates != nil && e.duplicates.tree == tree && e.duplicateKeysEdit {
+		return e.duplicates
+	}
+	if e.duplicates == nil || e.duplicates.tree != tree || e.duplicates.keys != e.duplicateKeys {
+		e.duplicates = newDuplicateIndex(tree, e.duplicateKeys)
+	}
+	return e.duplicates
+}
+
+// duplicatesFrame is what the frames of the duplicated nodes show.
+type duplicatesFrame struct {
+	tree *GraphView
+	keys string
+	// Selected node, when its duplicates are framed
+	node     uint64
+	selected bool
+}
+
+// applyDuplicates frames the duplicates of the selected node when asked, updating them only when the
+// selection changes.
+func (e *treeEngine) applyDuplicates() {
+	frame := duplicatesFrame{}
+	if e.showDuplicates && e.highlightDuplicates {
+		frame.tree = e.displayedTree().Tree
+		frame.keys = e.currentDuplicates().keys
+		frame.node, frame.selected = e.ecosystem.sys.SelectedNode()
+	}
+	if frame == e.duplicatesFrame {
+		return
+	}
+	e.duplicatesFrame = frame
+
+	framed := make(map[uint64]bool)
+	if frame.selected {
+		for _, id := range e.currentDuplicates().Duplicates(frame.node) {
+			framed[id] = true
+		}
+	}
+	e.ecosystem.sys.SetDuplicates(framed)
+}
+
+const duplicatesPanelWidth = 420
+
+// duplicatesPanelRows is the number of groups listed, the largest ones.
+const duplicatesPanelRows = 15
+
+// drawDuplicatesPanel lists the largest groups of nodes with the same state at (x, y). Clicking a
+// group goes to its first node and frames the others. It returns the area of the panel, empty when
+// hidden.
+func (e *treeEngine) drawDuplicatesPanel(x, y float32) rl.Rectangle {
+	if !e.showDuplicates {
+		return rl.Rectangle{}
+	}
+	duplicates := e.currentDuplicates()
+
+	lineHeight := float32(20)
+	rows := min(len(duplicates.groups), duplicatesPanelRows)
+	height := 24 + 10 + 28 + 24 + 10 + lineHeight*float32(max(rows, 1)) + 10
+	panelRec := rl.NewRectangle(x, y, duplicatesPanelWidth, height)
+	gui.Panel(panelRec, "Duplicates")
+
+	x = panelRec.X + 10
+	y = panelRec.Y + 24 + 10
+	keysSize := navButton("same keys")
+	gui.Label(rl.NewRectangle(x, y, keysSize.X, 24), "same keys")
+	keysRec := rl.NewRectangle(x+keysSize.X, y, 150, 24)
+	if gui.TextBox(keysRec, &e.duplicateKeys, 64, e.duplicateKeysEdit) {
+		e.duplicateKeysEdit = !e.duplicateKeysEdit
+	}
+	e.highlightDuplicates = gui.CheckBox(
+		rl.NewRectangle(keysRec.X+keysRec.Width+10, y+4, 16, 16), "frame selected", e.highlightDuplicates)
+	y += 28
+
+	nodes := 0
+	for _, g := range duplicates.groups {
+		nodes += len(g)
+	}
+	summary := fmt.Sprintf("%d groups, %d nodes in a group", len(duplicates.groups), nodes)
+	rl.DrawTextEx(e.font, summary, rl.NewVector2(x, y), 16, 0, rl.DarkGray)
+	y += 24 + 10
+
+	if len(duplicates.groups) == 0 {
+		rl.DrawTextEx(e.font, "every node has its own state", rl.NewVector2(x, y), 16, 0, rl.DarkGray)
+	}
+
+	mouse := rl.GetMousePosition()
+	for _, g := range duplicates.groups[:rows] {
+		row := rl.NewRectangle(panelRec.X, y, panelRec.Width, lineHeight)
+		color := rl.Black
+		if rl.CheckCollisionPointRec(mouse, row) {
+			color = rl.NewColor(45, 51, 107, 255)
+			rl.DrawRectangleRec(row, rl.NewColor(169, 181, 223, 120))
+			if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+				e.highlightDuplicates = true
+				e.ecosystem.sys.GoToNode(g[0])
+			}
+		}
+		ids := make([]string, 0, len(g))
+		for _, id := range g {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		text := fmt.Sprintf("%d nodes: %s", len(g), strings.Join(ids, ", "))
+		rl.DrawTextEx(e.font, truncateText(text, 50), rl.NewVector2(x, y), 16, 0, color)
+		y += lineHeight
+	}
+
+	return panelRec
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/render"
+	"github.com/ncruces/zenity"
+	"github.com/phuslu/log"
+)
+
+// viewExport is an export of the view waiting for the next frame: it cannot be drawn while the UI
+// texture is being drawn.
+type viewExport struct {
+	filename string
+	scale    float32
+}
+
+var exportScales = []float32{1, 2, 4, 8}
+
+// askViewExport asks for the resolution and the file of the exported view.
+func (e *treeEngine) askViewExport() {
+	items := make([]string, 0, len(exportScales))
+	for _, scale := range exportScales {
+		items = append(items, fmt.Sprintf("%gx (%dx%d)", scale, int(scale)*rl.GetScreenWidth(), int(scale)*rl.GetScreenHeight()))
+	}
+	choice, err := zenity.List("Resolution of the exported image", items,
+		zenity.Title("Export View"),
+		zenity.DefaultItems(items[1]))
+	if err != nil {
+		log.Info().Err(err).Msg("export view")
+		return
+	}
+
+	file, err := zenity.SelectFileSave(
+		zenity.Title("Export View"),
+		zenity.Filename("view.png"),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilter{Name: "PNG image", Patterns: []string{"*.png"}, CaseFold: true})
+	if err != nil {
+		log.Info().Err(err).Msg("export view")
+		return
+	}
+
+	e.pendingExport = &viewExport{filename: file, scale: exportScales[max(slices.Index(items, choice), 0)]}
+}
+
+func (e *treeEngine) exportView(export viewExport) {
+	image := e.ecosystem.sys.ExportView(&e.ecosystem.world, export.scale)
+	defer rl.UnloadImage(image)
+
+	if !rl.ExportImage(*image, export.filename) {
+		log.Error().Str("file", export.filename).Msg("cannot export view")
+		return
+	}
+	log.Info().Str("file", export.filename).Msg("view exported")
+}
+
+// drawNodeMenu draws the context menu of a node, opened with a right click. It returns the area of
+// the menu, empty when closed.
+func (e *treeEngine) drawNodeMenu() rl.Rectangle {
+	if rl.IsMouseButtonPressed(rl.MouseButtonRight) && !e.mouseCaptured {
+		if id, ok := e.ecosystem.sys.HoveredNode(); ok {
+			e.menuNode = &id
+			e.menuPosition = rl.GetMousePosition()
+		}
+	}
+	if e.menuNode == nil {
+		return rl.Rectangle{}
+	}
+	id := *e.menuNode
+
+	type menuItem struct {
+		text   string
+		action func()
+	}
+	items := []menuItem{{"Export plot as SVG", func() { e.exportNodePlot(id) }}}
+	if e.isBookmarked(id) {
+		items = append(items,
+			menuItem{"Edit bookmark note", func() { e.editBookmark(id) }},
+			menuItem{"Remove bookmark", func() { e.removeBookmark(id) }})
+	} else {
+		items = append(items, menuItem{"Bookmark", func() { e.editBookmark(id) }})
+	}
+
+	width := float32(0)
+	for _, item := range items {
+		width = max(width, navButton(item.text).X)
+	}
+	itemHeight := float32(30)
+	menuRec := rl.NewRectangle(
+		min(e.menuPosition.X, float32(rl.GetScreenWidth())-width-10),
+		min(e.menuPosition.Y, float32(rl.GetScreenHeight())-itemHeight*float32(len(items))),
+		width, itemHeight*float32(len(items)))
+
+	for i, item := range items {
+		if gui.Button(rl.NewRectangle(menuRec.X, menuRec.Y+float32(i)*itemHeight, width, itemHeight), item.text) {
+			item.action()
+			e.menuNode = nil
+			return menuRec
+		}
+	}
+	if rl.IsMouseButtonPressed(rl.MouseButtonLeft) && !rl.CheckCollisionPointRec(rl.GetMousePosition(), menuRec) {
+		e.menuNode = nil
+	}
+	return menuRec
+}
+
+// exportNodePlot writes the shapes of a node to a vector file.
+func (e *treeEngine) exportNodePlot(id uint64) {
+	file, err := zenity.SelectFileSave(
+		zenity.Title("Export Plot"),
+		zenity.Filename(fmt.Sprintf("node-%d.svg", id)),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilter{Name: "SVG image", Patterns: []string{"*.svg"}, CaseFold: true})
+	if err != nil {
+		log.Info().Err(err).Msg("export plot")
+		return
+	}
+
+	tree := e.displayedTree()
+	plot := render.NodePlot(tree.Shapes, tree.Tree.NodeForId(id), 100)
+	if err := render.Write(file, []render.Page{{Canvas: plot}}, 8); err != nil {
+		log.Error().Err(err).Str("file", file).Msg("cannot export plot")
+		return
+	}
+	log.Info().Str("file", file).Uint64("node", id).Msg("plot exported")
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nodeFilter is a boolean expression on the data of the nodes, like
+// `depth >= 3 && status == pruned-by-bound`. Clauses are joined with && and ||, && binding tighter.
+// A clause without operator only checks that the key exists. The `id` key is the node id, and
+// `depth` its depth unless the nodes have a data key with that name.
+type nodeFilter struct {
+	Expr string
+
+	// Disjunction of conjunctions
+	alternatives [][]filterClause
+}
+
+type filterClause struct {
+	key   string
+	op    string
+	value string
+}
+
+// Two-character operators first, so that `<=` is not read as `<`.
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">", "="}
+
+func parseFilter(expr string) (*nodeFilter, error) {
+	f := &nodeFilter{Expr: expr}
+	for _, alternative := range strings.Split(expr, "||") {
+		clauses := make([]filterClause, 0)
+		for _, c := range strings.Split(alternative, "&&") {
+			clause, err := parseFilterClause(strings.TrimSpace(c))
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, clause)
+		}
+		f.alternatives = append(f.alternatives, clauses)
+	}
+	return f, nil
+}
+
+func parseFilterClause(c string) (filterClause, error) {
+	if c == "" {
+		return filterClause{}, fmt.Errorf("empty filter clause")
+	}
+
+	for _, op := range filterOperators {
+		key, value, found := strings.Cut(c, op)
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key == "" {
+			return filterClause{}, fmt.Errorf("missing key in filter clause %q", c)
+		}
+		if value == "" {
+			return filterClause{}, fmt.Errorf("missing value in filter clause %q, quote an empty value: \"\"", c)
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if op == "=" {
+			op = "=="
+		}
+		return filterClause{key: key, op: op, value: value}, nil
+	}
+
+	return filterClause{key: c}, nil
+}
+
+// Match returns whether the node, at the given depth in its tree, matches the filter.
+func (f *nodeFilter) Match(n *DisplayableNode, depth int) bool {
+	for _, clauses := range f.alternatives {
+		matched := true
+		for _, c := range clauses {
+			if !c.match(n, depth) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c filterClause) match(n *DisplayableNode, depth int) bool {
+	value, ok := nodeValue(n, c.key, depth)
+	if !ok {
+		return false
+	}
+	if c.op == "" {
+		return true
+	}
+
+	cmp := compareValues(value, c.value)
+	switch c.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// nodeValue returns the value of a data key as a string, or of the id or the depth of the node.
+func nodeValue(n *DisplayableNode, key string, depth int) (string, bool) {
+	if key == "id" {
+		return strconv.FormatUint(n.Id, 10), true
+	}
+	value, ok := n.Value(key)
+	if !ok {
+		if key == "depth" {
+			return strconv.Itoa(depth), true
+		}
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// compareValues compares numerically when both values are numbers, and as strings otherwise.
+func compareValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// Apply returns the tree made of the matching nodes and their ancestors, so that the tree stays
+// connected.
+func (f *nodeFilter) Apply(tree *GraphView) *GraphView {
+	parents := tree.ParentIndices()
+	depths := nodeDepths(parents)
+	keep := make([]bool, len(tree.Nodes))
+	for i, n := range tree.Nodes {
+		if !f.Match(n, depths[i]) {
+			continue
+		}
+		for j := i; j != -1 && !keep[j]; j = parents[j] {
+			keep[j] = true
+		}
+	}
+
+	return tree.Subgraph(func(n *DisplayableNode) bool { return keep[tree.Lookup[n.Id]] })
+}
+
+// nodeDepths returns the depth of every node given the index of its parent, -1 for the roots which
+// are at depth 0.
+func nodeDepths(parents []int) []int {
+	depths := make([]int, len(parents))
+	known := make([]bool, len(parents))
+	path := make([]int, 0)
+	for i := range parents {
+		// Ancestors whose depth is unknown, bounded in case the parents form a cycle
+		path = path[:0]
+		j := i
+		for j != -1 && !known[j] && len(path) <= len(parents) {
+			path = append(path, j)
+			j = parents[j]
+		}
+		depth := -1
+		if j != -1 && known[j] {
+			depth = depths[j]
+		}
+		for k := len(path) - 1; k >= 0; k-- {
+			depth++
+			depths[path[k]] = depth
+			known[path[k]] = true
+		}
+	}
+	return depths
+}
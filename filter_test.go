@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		// Clauses of every alternative, nil when the expression is malformed
+		want [][]filterClause
+	}{
+		{"depth >= 3", [][]filterClause{{{key: "depth", op: ">=", value: "3"}}}},
+		{"status = pruned", [][]filterClause{{{key: "status", op: "==", value: "pruned"}}}},
+		{`name == "a b"`, [][]filterClause{{{key: "name", op: "==", value: "a b"}}}},
+		{`name != ""`, [][]filterClause{{{key: "name", op: "!=", value: ""}}}},
+		{"obj", [][]filterClause{{{key: "obj"}}}},
+		{"a < 1 && b || c > 2", [][]filterClause{
+			{{key: "a", op: "<", value: "1"}, {key: "b"}},
+			{{key: "c", op: ">", value: "2"}},
+		}},
+		{"", nil},
+		{"   ", nil},
+		{"depth >= 3 &&", nil},
+		{"&& depth >= 3", nil},
+		{"a || || b", nil},
+		{"== 3", nil},
+		{"depth >=", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parseFilter(tt.expr)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("parseFilter(%q) = %v, want an error", tt.expr, f.alternatives)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFilter(%q): %v", tt.expr, err)
+			}
+			if !slices.EqualFunc(f.alternatives, tt.want, slices.Equal) {
+				t.Errorf("parseFilter(%q) = %v, want %v", tt.expr, f.alternatives, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeDepths(t *testing.T) {
+	tests := []struct {
+		name    string
+		parents []int
+		want    []int
+	}{
+		{"empty", nil, []int{}},
+		{"root", []int{-1}, []int{0}},
+		{"parents first", []int{-1, 0, 0, 1, 3}, []int{0, 1, 1, 2, 3}},
+		{"children first", []int{3, 2, -1, 2}, []int{2, 1, 0, 1}},
+		{"two roots", []int{-1, 0, -1, 2}, []int{0, 1, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeDepths(tt.parents); !slices.Equal(got, tt.want) {
+				t.Errorf("nodeDepths(%v) = %v, want %v", tt.parents, got, tt.want)
+			}
+		})
+	}
+
+	// Parents in a cycle have no depth, but do not hang
+	nodeDepths([]int{1, 2, 0})
+}
+
+func TestFilterApply(t *testing.T) {
+	tree := testTree(
+		testNode{0, -1, nil},
+		testNode{1, 0, map[string]any{"status": "branched"}},
+		testNode{2, 0, map[string]any{"status": "pruned-by-bound"}},
+		testNode{3, 1, map[string]any{"status": "pruned-by-bound"}},
+		testNode{4, 1, map[string]any{"status": "infeasible"}},
+		testNode{5, 3, map[string]any{"status": "pruned-by-bound", "depth": 10}},
+	).Tree
+
+	tests := []struct {
+		expr string
+		want []uint64
+	}{
+		{"depth >= 2", []uint64{0, 1, 3, 4, 5}},
+		{"depth == 1", []uint64{0, 1, 2}},
+		{"depth >= 2 && status == pruned-by-bound", []uint64{0, 1, 3, 5}},
+		{"depth == 0", []uint64{0}},
+		// The data key takes precedence
+		{"depth == 3", nil},
+		{"depth == 10", []uint64{0, 1, 3, 5}},
+		{"id = 4 || id = 2", []uint64{0, 1, 2, 4}},
+		{"status == open", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parseFilter(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]uint64, 0)
+			for _, n := range f.Apply(tree).Nodes {
+				ids = append(ids, n.Id)
+			}
+			slices.Sort(ids)
+			if len(tt.want) == 0 && len(ids) == 0 {
+				return
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("filter %q keeps %v, want %v", tt.expr, ids, tt.want)
+			}
+		})
+	}
+}
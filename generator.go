@@ -3,58 +3,52 @@ package main
 import (
 	"fmt"
 	"math/rand/v2"
-	"strconv"
+
+	"github.com/iancoleman/orderedmap"
 )
 
-func GenerateLargeInput(nbNodes int) InputTree {
-	nodes := make([]Node, nbNodes)
-	for i := 0; i < nbNodes; i++ {
-		parentIds := make([]string, 0)
-		if i > 0 {
-			parentIds = append(parentIds, strconv.Itoa(rand.IntN(i)))
-		}
-		n := Node{
-			Id:        strconv.Itoa(i),
-			ParentIds: parentIds,
-			Info:      fmt.Sprintf("Node %d\nIts parent is node %d", i, parentIds),
-			ShortInfo: fmt.Sprintf("Node %d", i),
-		}
+func generatedNode(id int, parentId int) *TNode {
+	data := orderedmap.New()
+	data.Set("info", fmt.Sprintf("Node %d", id))
+	data.Set("parent", parentId)
+	return &TNode{
+		Id:       uint64(id),
+		ParentId: int64(parentId),
+		Data:     *data,
+	}
+}
 
-		nodes[i] = n
+// GenerateLargeInput generates a tree where each node has a random parent: the tree is wide and
+// shallow.
+func GenerateLargeInput(nbNodes int) Tree {
+	nodes := make([]*TNode, nbNodes)
+	for i := 1; i < nbNodes; i++ {
+		nodes[i] = generatedNode(i, rand.IntN(i))
 	}
 
-	return InputTree{
+	return Tree{
+		Name:  "large",
 		Nodes: nodes,
 	}
 }
 
-func GenerateDeepInput(nbNodes int) InputTree {
-	nodes := make([]Node, nbNodes)
+// GenerateDeepInput generates a tree where nodes tend to be children of the last nodes: the tree is
+// narrow and deep.
+func GenerateDeepInput(nbNodes int) Tree {
+	nodes := make([]*TNode, nbNodes)
 	parentId := 0
 	rate := float32(0.7)
-	for i := 0; i < nbNodes; i++ {
-		n := Node{
-			Id:        strconv.Itoa(i),
-			Info:      fmt.Sprintf("Node %d\nIts parent is node %d", i, parentId),
-			ShortInfo: fmt.Sprintf("Node %d", i),
-		}
-		if parentId != i {
-			n.ParentIds = []string{strconv.Itoa(parentId)}
-		}
+	for i := 1; i < nbNodes; i++ {
+		nodes[i] = generatedNode(i, parentId)
 
 		for parentId != i && rand.Float32() > rate {
 			parentId++
 			rate -= 1 / float32(nbNodes)
 		}
-		// if parentId > 0 && parentId != i && rand.Float32() > 0.9 {
-		// 	n.ParentIds = append(n.ParentIds, nodes[rand.IntN(parentId)].Id)
-		// 	fmt.Println("2 parents: ", n.Id)
-		// }
-		nodes[i] = n
-
 	}
 
-	return InputTree{
+	return Tree{
+		Name:  "deep",
 		Nodes: nodes,
 	}
 }
@@ -10,6 +10,7 @@ require (
 	github.com/gen2brain/raylib-go/raylib v0.0.0-20250109172833-6dbba4f81a9b
 	github.com/gverger/go-graph-layout v0.3.1
 	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.18.0
 	github.com/ncruces/zenity v0.10.14
 	github.com/osuushi/triangulate v0.0.0-20220629121601-b0217b0c87c8
 	github.com/phuslu/log v1.0.113
@@ -3,6 +3,7 @@ package graph
 import (
 	"fmt"
 	"iter"
+	"sort"
 
 	"github.com/phuslu/log"
 )
@@ -121,3 +122,49 @@ func AssertNoErr(err error, msg string) {
 	}
 	log.Fatal().Err(err)
 }
+
+// ParentIndices returns, for each node index, the index of its parent, or -1 for roots. If a node
+// has several parents, one of them is returned.
+func (g *Graph[Node, ID]) ParentIndices() []int {
+	parents := make([]int, len(g.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+	for a, dst := range g.Edges {
+		for b := range dst {
+			parents[b] = a
+		}
+	}
+	return parents
+}
+
+// ChildrenIndices returns the indices of the children of node i, in insertion order.
+func (g *Graph[Node, ID]) ChildrenIndices(i int) []int {
+	children := make([]int, 0, len(g.Edges[i]))
+	for c := range g.Edges[i] {
+		children = append(children, c)
+	}
+	sort.Ints(children)
+	return children
+}
+
+// Subgraph returns the graph made of the nodes to keep, and the edges between them.
+func (g *Graph[Node, ID]) Subgraph(keep func(Node) bool) *Graph[Node, ID] {
+	res := NewGraph(g.NodeID)
+
+	for _, v := range g.Nodes {
+		if keep(v) {
+			res.addNode(v)
+		}
+	}
+
+	for _, v := range res.Nodes {
+		for n := range g.Children(v) {
+			if res.HasNode(n) {
+				res.addEdge(v, n)
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/phuslu/log"
+)
+
+// Same spacing as the layered layout
+const (
+	treeLayoutDeltaX     = 120
+	treeLayoutNodeHeight = 90
+	treeLayoutMarginY    = 60
+)
+
+// ComputeTreeCoordinates places the leaves on consecutive slots and centers every node above its
+// children. It is much faster than the layered layout, but uses more horizontal space.
+func ComputeTreeCoordinates[Node any, ID comparable](input Graph[Node, ID]) map[ID]Position {
+	log.Info().Msg("Computing tree coordinates")
+	now := time.Now()
+
+	parents := input.ParentIndices()
+	positions := make(map[ID]Position, len(input.Nodes))
+
+	type frame struct {
+		node     int
+		depth    int
+		children []int
+		next     int
+	}
+
+	nextSlot := 0
+	for root, parent := range parents {
+		if parent != -1 {
+			continue
+		}
+
+		// Post-order traversal, without recursion since search trees can be very deep
+		stack := []*frame{{node: root, children: input.ChildrenIndices(root)}}
+		for len(stack) > 0 {
+			f := stack[len(stack)-1]
+			if f.next < len(f.children) {
+				c := f.children[f.next]
+				f.next++
+				stack = append(stack, &frame{node: c, depth: f.depth + 1, children: input.ChildrenIndices(c)})
+				continue
+			}
+			stack = stack[:len(stack)-1]
+
+			x := nextSlot * treeLayoutDeltaX
+			if len(f.children) == 0 {
+				nextSlot++
+			} else {
+				first := positions[input.NodeID(input.Nodes[f.children[0]])]
+				last := positions[input.NodeID(input.Nodes[f.children[len(f.children)-1]])]
+				x = (first.X + last.X) / 2
+			}
+			positions[input.NodeID(input.Nodes[f.node])] = Position{
+				X: x,
+				Y: treeLayoutNodeHeight/2 + f.depth*(treeLayoutNodeHeight+treeLayoutMarginY),
+			}
+		}
+	}
+
+	log.Info().Dur("duration", time.Since(now)).Msg("coordinates computed")
+	return positions
+}
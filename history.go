@@ -0,0 +1,77 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Number of selections kept in the history
+const maxHistory = 200
+
+type historyEntry struct {
+	node uint64
+	// Camera when leaving the node
+	camera rl.Camera2D
+}
+
+// navigationHistory records the selected nodes, whatever selected them, to go back and forward.
+type navigationHistory struct {
+	entries []historyEntry
+	// Index of the entry of the selected node, -1 when empty
+	current int
+	// Camera at the last frame, before a new selection moves it
+	lastCamera rl.Camera2D
+}
+
+func newNavigationHistory() *navigationHistory {
+	return &navigationHistory{current: -1}
+}
+
+// recordHistory adds the selected node to the history when it changed since the last frame.
+func (e *treeEngine) recordHistory() {
+	h := e.history
+	id, ok := e.ecosystem.sys.SelectedNode()
+	if ok && (h.current == -1 || h.entries[h.current].node != id) {
+		if h.current >= 0 {
+			h.entries[h.current].camera = h.lastCamera
+		}
+		h.entries = append(h.entries[:h.current+1], historyEntry{node: id})
+		if len(h.entries) > maxHistory {
+			h.entries = h.entries[len(h.entries)-maxHistory:]
+		}
+		h.current = len(h.entries) - 1
+	}
+	h.lastCamera = e.ecosystem.sys.Camera()
+}
+
+// moveInHistory selects the previous (step -1) or next (step 1) node of the history, and restores
+// the camera as it was. Nodes that are hidden now are skipped.
+func (e *treeEngine) moveInHistory(step int) {
+	h := e.history
+	if h.current == -1 {
+		return
+	}
+	h.entries[h.current].camera = e.ecosystem.sys.Camera()
+	for i := h.current + step; i >= 0 && i < len(h.entries); i += step {
+		if e.ecosystem.sys.SelectNode(h.entries[i].node) {
+			h.current = i
+			if camera := h.entries[i].camera; camera.Zoom != 0 {
+				e.ecosystem.sys.SetCamera(camera)
+				h.lastCamera = camera
+			}
+			return
+		}
+	}
+}
+
+// handleHistoryKeys goes back with Alt+Left or the back button of the mouse, and forward with
+// Alt+Right or the forward button.
+func (e *treeEngine) handleHistoryKeys() {
+	if e.findMode {
+		return
+	}
+	alt := rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt)
+	if (alt && rl.IsKeyPressed(rl.KeyLeft)) || rl.IsMouseButtonPressed(rl.MouseButtonSide) || rl.IsMouseButtonPressed(rl.MouseButtonBack) {
+		e.moveInHistory(-1)
+	}
+	if (alt && rl.IsKeyPressed(rl.KeyRight)) || rl.IsMouseButtonPressed(rl.MouseButtonExtra) || rl.IsMouseButtonPressed(rl.MouseButtonForward) {
+		e.moveInHistory(1)
+	}
+}
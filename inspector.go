@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/systems"
+	"github.com/iancoleman/orderedmap"
+	"github.com/phuslu/log"
+)
+
+const (
+	inspectorWidth      = 420
+	inspectorLineHeight = 20
+	inspectorIndent     = 16
+)
+
+// inspector is a panel docked on a side of the window, showing the data and the plot of the
+// selected node.
+type inspector struct {
+	// Docked on the left of the window instead of the right
+	left bool
+	// Paths of the nested values whose content is hidden
+	folded map[string]bool
+	scroll rl.Vector2
+
+	// Zoom and move of the plot, reset when another node is inspected
+	node   uint64
+	zoom   float32
+	pan    rl.Vector2
+	moving bool
+}
+
+func (i *inspector) resetPlot(node uint64) {
+	i.node = node
+	i.zoom = 1
+	i.pan = rl.Vector2{}
+}
+
+// inspectorRow is a line of the data of the node: a value, or a map or array whose content is on the
+// next lines.
+type inspectorRow struct {
+	path   string
+	depth  int
+	key    string
+	value  any
+	nested bool
+	// Text displayed instead of the value, if any
+	text string
+	// Title of a section, without value
+	heading bool
+}
+
+type keyValue struct {
+	key   string
+	value any
+}
+
+// nestedValues returns the content of a map or an array, nil for other values.
+func nestedValues(value any) []keyValue {
+	var m *orderedmap.OrderedMap
+	switch v := value.(type) {
+	case orderedmap.OrderedMap:
+		m = &v
+	case *orderedmap.OrderedMap:
+		m = v
+	case []any:
+		values := make([]keyValue, 0, len(v))
+		for i, item := range v {
+			values = append(values, keyValue{key: fmt.Sprintf("[%d]", i), value: item})
+		}
+		return values
+	default:
+		return nil
+	}
+	values := make([]keyValue, 0, len(m.Keys()))
+	for _, key := range m.Keys() {
+		item, _ := m.Get(key)
+		values = append(values, keyValue{key: key, value: item})
+	}
+	return values
+}
+
+func appendInspectorRows(rows []inspectorRow, path string, depth int, key string, value any, folded map[string]bool) []inspectorRow {
+	children := nestedValues(value)
+	rows = append(rows, inspectorRow{path: path, depth: depth, key: key, value: value, nested: children != nil})
+	if children == nil || folded[path] {
+		return rows
+	}
+	for _, c := range children {
+		rows = appendInspectorRows(rows, path+"/"+c.key, depth+1, c.key, c.value, folded)
+	}
+	return rows
+}
+
+// inspectorText returns the value as displayed: maps and arrays are summed up by their size.
+func inspectorText(row inspectorRow) string {
+	if row.text != "" {
+		return row.text
+	}
+	if !row.nested {
+		return fmt.Sprint(row.value)
+	}
+	size := len(nestedValues(row.value))
+	if _, ok := row.value.([]any); ok {
+		return fmt.Sprintf("[%d items]", size)
+	}
+	return fmt.Sprintf("{%d keys}", size)
+}
+
+// clipboardText returns the value as copied: strings as they are, other values in JSON.
+func clipboardText(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("cannot copy value")
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
+
+// nodeJSON returns the node in the format of the tree files.
+func (e *treeEngine) nodeJSON(n *DisplayableNode) string {
+	node := TNode{Id: n.Id, ParentId: -1, Plot: make([]ShapePos, 0, len(n.Transform))}
+	if path := e.crumbs.path(e.displayedTree().Tree, n.Id); len(path) > 1 {
+		node.ParentId = int64(e.displayedTree().Tree.Nodes[path[len(path)-2]].Id)
+	}
+	for _, tr := range n.Transform {
+		pos := ShapePos{Id: tr.Id, X: tr.X, Y: tr.Y}
+		if tr.Highlight {
+			pos.FillColor = "green"
+		}
+		node.Plot = append(node.Plot, pos)
+	}
+	if n.Data != nil {
+		node.Data = *n.Data
+	}
+	return clipboardText(node)
+}
+
+// viewArea returns the area of the window left to the trees by the docked inspector.
+func (e *treeEngine) viewArea() rl.Rectangle {
+	area := rl.NewRectangle(0, 0, float32(rl.GetScreenWidth()), float32(rl.GetScreenHeight()))
+	if !e.showInspector {
+		return area
+	}
+	area.Width -= inspectorWidth
+	if e.inspector.left {
+		area.X += inspectorWidth
+	}
+	return area
+}
+
+// drawInspector draws the inspector from y to the bottom of the window. It returns the area of the
+// panel, empty when hidden.
+func (e *treeEngine) drawInspector(y float32) rl.Rectangle {
+	if !e.showInspector {
+		return rl.Rectangle{}
+	}
+	i := &e.inspector
+	if i.folded == nil {
+		i.folded = make(map[string]bool)
+	}
+
+	x := float32(rl.GetScreenWidth()) - inspectorWidth
+	if i.left {
+		x = 0
+	}
+	panelRec := rl.NewRectangle(x, y, inspectorWidth, float32(rl.GetScreenHeight())-y)
+
+	id, selected := e.ecosystem.sys.SelectedNode()
+	tree := e.displayedTree()
+	if selected {
+		_, selected = tree.Tree.Lookup[id]
+	}
+	if !selected {
+		gui.Panel(panelRec, "Inspector")
+		rl.DrawTextEx(e.font, "select a node to inspect it", rl.NewVector2(x+10, y+24+10), 16, 0, rl.DarkGray)
+		return panelRec
+	}
+	n := tree.Tree.NodeForId(id)
+	if i.node != id || i.zoom == 0 {
+		i.resetPlot(id)
+	}
+	gui.Panel(panelRec, fmt.Sprintf("Node %d", id))
+
+	// Buttons
+	offsetX := x + 10
+	y += 24 + 6
+	buttons := []struct {
+		text   string
+		action func()
+	}{
+		{"Copy JSON", func() { rl.SetClipboardText(e.nodeJSON(n)) }},
+		{"Reset zoom", func() { i.resetPlot(id) }},
+		{dockText(i.left), func() { i.left = !i.left }},
+	}
+	for _, b := range buttons {
+		size := navButton(b.text)
+		if gui.Button(rl.NewRectangle(offsetX, y, size.X, 24), b.text) {
+			b.action()
+		}
+		offsetX += size.X + 10
+	}
+	y += 24 + 6
+
+	// Plot
+	plotRec := rl.NewRectangle(x+10, y, inspectorWidth-20, inspectorWidth-20)
+	e.drawInspectorPlot(tree.Shapes, n, plotRec)
+	y += plotRec.Height + 10
+
+	// Data, after its changes from the parent
+	rows := make([]inspectorRow, 0)
+	if path := e.crumbs.path(tree.Tree, id); len(path) > 1 {
+		changes := systems.DataChanges(tree.Tree.Nodes[path[len(path)-2]], n)
+		if len(changes) > 0 {
+			rows = append(rows, inspectorRow{key: "Changed from parent", heading: true})
+		}
+		for _, c := range changes {
+			row := inspectorRow{path: "changes/" + c.Key, key: c.Key, value: c.New}
+			if c.Removed {
+				row.value = c.Old
+			}
+			row.text = strings.TrimPrefix(c.String(), c.Key+": ")
+			rows = append(rows, row)
+		}
+		if len(changes) > 0 {
+			rows = append(rows, inspectorRow{key: "Data", heading: true})
+		}
+	}
+	if n.Data != nil {
+		for _, kv := range nestedValues(n.Data) {
+			rows = appendInspectorRows(rows, kv.key, 0, kv.key, kv.value, i.folded)
+		}
+	}
+	if n.Metrics != nil {
+		rows = append(rows, inspectorRow{key: "Metrics", heading: true})
+		for _, key := range n.Metrics.Keys() {
+			value, _ := n.Metrics.Get(key)
+			rows = appendInspectorRows(rows, "metrics/"+key, 0, key, value, i.folded)
+		}
+	}
+	dataRec := rl.NewRectangle(x+10, y, inspectorWidth-20, max(panelRec.Y+panelRec.Height-10-y, 2*inspectorLineHeight))
+	content := rl.NewRectangle(0, 0, dataRec.Width-16, float32(len(rows))*inspectorLineHeight+10)
+	view := rl.Rectangle{}
+	gui.ScrollPanel(dataRec, "", content, &i.scroll, &view)
+
+	rl.BeginScissorMode(int32(view.X), int32(view.Y), int32(view.Width), int32(view.Height))
+	mouse := rl.GetMousePosition()
+	rowY := view.Y + 5 + i.scroll.Y
+	for _, row := range rows {
+		if rowY+inspectorLineHeight < view.Y || rowY > view.Y+view.Height {
+			rowY += inspectorLineHeight
+			continue
+		}
+		if row.heading {
+			rl.DrawTextEx(e.font, row.key, rl.NewVector2(view.X+5, rowY+2), 16, 0, rl.DarkGray)
+			rowY += inspectorLineHeight
+			continue
+		}
+		rowRec := rl.NewRectangle(view.X, rowY, view.Width, inspectorLineHeight)
+		copyRec := rl.NewRectangle(view.X+view.Width-22, rowY+1, 18, 18)
+		hovered := rl.CheckCollisionPointRec(mouse, rowRec) && rl.CheckCollisionPointRec(mouse, view)
+		if hovered {
+			rl.DrawRectangleRec(rowRec, rl.NewColor(169, 181, 223, 120))
+		}
+
+		keyX := view.X + 5 + float32(row.depth*inspectorIndent)
+		key := row.key
+		if row.nested {
+			if i.folded[row.path] {
+				key = "+ " + key
+			} else {
+				key = "- " + key
+			}
+		}
+		rl.DrawTextEx(e.font, key, rl.NewVector2(keyX, rowY+2), 16, 0, rl.NewColor(45, 51, 107, 255))
+		keyWidth := max(rl.MeasureTextEx(e.font, key, 16, 0).X+10, 120-float32(row.depth*inspectorIndent))
+		valueWidth := copyRec.X - 5 - (keyX + keyWidth)
+		value := fitText(e.font, inspectorText(row), valueWidth)
+		rl.DrawTextEx(e.font, value, rl.NewVector2(keyX+keyWidth, rowY+2), 16, 0, rl.Black)
+
+		if hovered {
+			if gui.Button(copyRec, gui.IconText(gui.ICON_FILE_COPY, "")) {
+				rl.SetClipboardText(clipboardText(row.value))
+			} else if row.nested && rl.IsMouseButtonPressed(rl.MouseButtonLeft) && !rl.CheckCollisionPointRec(mouse, copyRec) {
+				i.folded[row.path] = !i.folded[row.path]
+			}
+		}
+		rowY += inspectorLineHeight
+	}
+	rl.EndScissorMode()
+
+	return panelRec
+}
+
+// drawInspectorPlot draws the plot of the node, zoomed with the mouse wheel and moved by dragging.
+// Clicking a placement flags the nodes placing the same item.
+func (e *treeEngine) drawInspectorPlot(shapes []systems.ShapeDefinition, n *DisplayableNode, area rl.Rectangle) {
+	i := &e.inspector
+	rl.DrawRectangleRec(area, rl.White)
+	rl.DrawRectangleLinesEx(area, 1, rl.LightGray)
+
+	mouse := rl.GetMousePosition()
+	hovered := rl.CheckCollisionPointRec(mouse, area)
+	if hovered {
+		if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+			// zoom around the mouse
+			zoom := rl.Clamp(i.zoom*float32(math.Pow(1.2, float64(wheel))), 0.5, 200)
+			center := rl.NewVector2(area.X+area.Width/2, area.Y+area.Height/2)
+			relative := rl.Vector2Subtract(mouse, rl.Vector2Add(center, i.pan))
+			i.pan = rl.Vector2Add(i.pan, rl.Vector2Scale(relative, 1-zoom/i.zoom))
+			i.zoom = zoom
+		}
+		if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+			i.moving = true
+		}
+	}
+	if !rl.IsMouseButtonDown(rl.MouseButtonLeft) {
+		i.moving = false
+	}
+	if i.moving {
+		i.pan = rl.Vector2Add(i.pan, rl.GetMouseDelta())
+	}
+
+	rl.BeginScissorMode(int32(area.X)+1, int32(area.Y)+1, int32(area.Width)-2, int32(area.Height)-2)
+	systems.DrawPlot(shapes, n.Transform, area, i.zoom, i.pan)
+	placement, shape := -1, -1
+	if hovered && len(n.Transform) > 0 {
+		scale, origin := systems.PlotScale(shapes, n.Transform, area, i.zoom, i.pan)
+		placement, shape = systems.PlacementAt(shapes, n.Transform, (mouse.X-origin.X)/scale, (origin.Y-mouse.Y)/scale)
+	}
+	if placement != -1 {
+		systems.DrawPlotOutline(shapes, n.Transform, placement, area, i.zoom, i.pan, systems.Palette.Bookmark)
+	}
+	rl.EndScissorMode()
+
+	if placement != -1 {
+		if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+			tr := n.Transform[placement]
+			e.ecosystem.sys.SelectPlacement(&tr)
+		}
+		text := systems.PlacementText(shapes, n.Transform, placement, shape)
+		size := rl.MeasureTextEx(e.font, text, 16, 0)
+		tooltip := rl.NewRectangle(
+			min(mouse.X+20, float32(rl.GetScreenWidth())-size.X-20), max(mouse.Y-size.Y-20, 0), size.X+10, size.Y+10)
+		rl.DrawRectangleRec(tooltip, rl.NewColor(221, 221, 221, 221))
+		rl.DrawRectangleLinesEx(tooltip, 1, rl.Gray)
+		rl.DrawTextEx(e.font, text, rl.NewVector2(tooltip.X+5, tooltip.Y+5), 16, 0, rl.Black)
+	}
+
+	if len(n.Transform) == 0 {
+		rl.DrawTextEx(e.font, "no plot", rl.NewVector2(area.X+10, area.Y+10), 16, 0, rl.DarkGray)
+	}
+	zoom := "x" + strconv.FormatFloat(float64(i.zoom), 'f', 1, 32)
+	rl.DrawTextEx(e.font, zoom, rl.NewVector2(area.X+5, area.Y+area.Height-20), 16, 0, rl.DarkGray)
+}
+
+func dockText(left bool) string {
+	if left {
+		return "Dock right"
+	}
+	return "Dock left"
+}
+
+// fitText truncates the text so that it is not wider than width.
+func fitText(font rl.Font, text string, width float32) string {
+	if rl.MeasureTextEx(font, text, 16, 0).X <= width {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) > 200 {
+		runes = runes[:200]
+	}
+	for len(runes) > 0 && rl.MeasureTextEx(font, string(runes)+"...", 16, 0).X > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
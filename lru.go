@@ -0,0 +1,113 @@
+package main
+
+import "container/list"
+
+// lruCache keeps the most recently used values, up to capacity. It is not safe for concurrent use.
+type lruCache[K comparable, V any] struct {
+	capacity int
+	order    *list.List
+	items    map[K]*list.Element
+
+	// When weight is set, the oldest values are also evicted while the total weight of the values
+	// exceeds the budget.
+	budget int64
+	weight func(V) int64
+	total  int64
+
+	// Called with the values evicted, if set
+	evicted func(K, V)
+}
+
+type lruItem[K comparable, V any] struct {
+	key    K
+	value  V
+	weight int64
+}
+
+func newLRUCache[K comparable, V any](capacity int) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+// newWeightedLRUCache creates a cache also limited by the total weight of its values. evicted is
+// called with the values leaving the cache, except the ones removed with Take.
+func newWeightedLRUCache[K comparable, V any](capacity int, budget int64, weight func(V) int64, evicted func(K, V)) *lruCache[K, V] {
+	c := newLRUCache[K, V](capacity)
+	c.budget = budget
+	c.weight = weight
+	c.evicted = evicted
+	return c
+}
+
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	elt, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(elt)
+	return elt.Value.(*lruItem[K, V]).value, true
+}
+
+func (c *lruCache[K, V]) Has(key K) bool {
+	_, ok := c.items[key]
+	return ok
+}
+
+func (c *lruCache[K, V]) Put(key K, value V) {
+	weight := int64(0)
+	if c.weight != nil {
+		weight = c.weight(value)
+	}
+
+	if elt, ok := c.items[key]; ok {
+		item := elt.Value.(*lruItem[K, V])
+		c.total += weight - item.weight
+		item.value = value
+		item.weight = weight
+		c.order.MoveToFront(elt)
+	} else {
+		c.items[key] = c.order.PushFront(&lruItem[K, V]{key: key, value: value, weight: weight})
+		c.total += weight
+	}
+
+	for c.order.Len() > c.capacity || (c.weight != nil && c.total > c.budget && c.order.Len() > 0) {
+		c.evict(c.order.Back())
+	}
+}
+
+// Take removes the value from the cache and returns it.
+func (c *lruCache[K, V]) Take(key K) (V, bool) {
+	elt, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	item := c.remove(elt)
+	return item.value, true
+}
+
+// Clear evicts every value.
+func (c *lruCache[K, V]) Clear() {
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+func (c *lruCache[K, V]) evict(elt *list.Element) {
+	item := c.remove(elt)
+	if c.evicted != nil {
+		c.evicted(item.key, item.value)
+	}
+}
+
+func (c *lruCache[K, V]) remove(elt *list.Element) *lruItem[K, V] {
+	item := elt.Value.(*lruItem[K, V])
+	c.order.Remove(elt)
+	delete(c.items, item.key)
+	c.total -= item.weight
+	return item
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// lruOp is a call on the cache: "put" with a value, "get", "take" or "clear".
+type lruOp struct {
+	op    string
+	key   string
+	value int64
+}
+
+func TestLRUCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		// Weighted by the values when set
+		budget  int64
+		ops     []lruOp
+		kept    []string
+		evicted []string
+	}{
+		{
+			name:     "under capacity",
+			capacity: 3,
+			ops:      []lruOp{{"put", "a", 1}, {"put", "b", 1}},
+			kept:     []string{"a", "b"},
+		},
+		{
+			name:     "oldest evicted first",
+			capacity: 2,
+			ops:      []lruOp{{"put", "a", 1}, {"put", "b", 1}, {"put", "c", 1}, {"put", "d", 1}},
+			kept:     []string{"c", "d"},
+			evicted:  []string{"a", "b"},
+		},
+		{
+			name:     "get refreshes",
+			capacity: 2,
+			ops:      []lruOp{{"put", "a", 1}, {"put", "b", 1}, {"get", "a", 0}, {"put", "c", 1}},
+			kept:     []string{"a", "c"},
+			evicted:  []string{"b"},
+		},
+		{
+			name:     "put again refreshes",
+			capacity: 2,
+			ops:      []lruOp{{"put", "a", 1}, {"put", "b", 1}, {"put", "a", 2}, {"put", "c", 1}},
+			kept:     []string{"a", "c"},
+			evicted:  []string{"b"},
+		},
+		{
+			name:     "zero capacity keeps one",
+			capacity: 0,
+			ops:      []lruOp{{"put", "a", 1}, {"put", "b", 1}},
+			kept:     []string{"b"},
+			evicted:  []string{"a"},
+		},
+		{
+			name:     "over budget",
+			capacity: 10,
+			budget:   5,
+			ops:      []lruOp{{"put", "a", 2}, {"put", "b", 2}, {"put", "c", 2}},
+			kept:     []string{"b", "c"},
+			evicted:  []string{"a"},
+		},
+		{
+			name:     "heavy value evicts several",
+			capacity: 10,
+			budget:   5,
+			ops:      []lruOp{{"put", "a", 2}, {"put", "b", 2}, {"get", "a", 0}, {"put", "c", 4}},
+			kept:     []string{"c"},
+			evicted:  []string{"b", "a"},
+		},
+		{
+			name:     "value heavier than the budget",
+			capacity: 10,
+			budget:   5,
+			ops:      []lruOp{{"put", "a", 2}, {"put", "b", 6}},
+			evicted:  []string{"a", "b"},
+		},
+		{
+			name:     "weight updated by put",
+			capacity: 10,
+			budget:   5,
+			ops:      []lruOp{{"put", "a", 4}, {"put", "b", 1}, {"put", "a", 1}, {"put", "c", 3}},
+			kept:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "take frees the weight without eviction",
+			capacity: 10,
+			budget:   5,
+			ops:      []lruOp{{"put", "a", 4}, {"take", "a", 0}, {"put", "b", 5}},
+			kept:     []string{"b"},
+		},
+		{
+			name:     "clear evicts the oldest first",
+			capacity: 3,
+			ops:      []lruOp{{"put", "a", 1}, {"put", "b", 1}, {"get", "a", 0}, {"clear", "", 0}},
+			evicted:  []string{"b", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evicted []string
+			onEvicted := func(k string, _ int64) { evicted = append(evicted, k) }
+			var c *lruCache[string, int64]
+			if tt.budget > 0 {
+				c = newWeightedLRUCache(tt.capacity, tt.budget, func(v int64) int64 { return v }, onEvicted)
+			} else {
+				c = newLRUCache[string, int64](tt.capacity)
+				c.evicted = onEvicted
+			}
+
+			for _, op := range tt.ops {
+				switch op.op {
+				case "put":
+					c.Put(op.key, op.value)
+				case "get":
+					c.Get(op.key)
+				case "take":
+					c.Take(op.key)
+				case "clear":
+					c.Clear()
+				}
+			}
+
+			var kept []string
+			for _, k := range []string{"a", "b", "c", "d"} {
+				if c.Has(k) {
+					kept = append(kept, k)
+				}
+			}
+			if !slices.Equal(kept, tt.kept) {
+				t.Errorf("kept %v, want %v", kept, tt.kept)
+			}
+			if !slices.Equal(evicted, tt.evicted) {
+				t.Errorf("evicted %v, want %v", evicted, tt.evicted)
+			}
+		})
+	}
+}
+
+func TestLRUCacheValues(t *testing.T) {
+	c := newLRUCache[string, int](2)
+	c.Put("a", 1)
+	c.Put("a", 2)
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Errorf("Get(a) = %v, %v, want 2, true", v, ok)
+	}
+	if v, ok := c.Take("a"); !ok || v != 2 {
+		t.Errorf("Take(a) = %v, %v, want 2, true", v, ok)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get(a) found a taken value")
+	}
+	if _, ok := c.Take("a"); ok {
+		t.Errorf("Take(a) found a taken value")
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/gverger/optimview/graph"
@@ -13,41 +15,55 @@ type ShapeTransform = systems.ShapeTransform
 
 type GraphView = graph.Graph[*DisplayableNode, uint64]
 
-type Node struct {
-	Id        string   `json:"id"`
-	ParentIds []string `json:"parentIds"`
-	Info      string   `json:"info"`
-	ShortInfo string   `json:"shortInfo"`
-	SvgImage  string   `json:"svg"`
-	Hidden    bool     `json:"hidden"`
-}
-
 type Input struct {
-	Trees map[string]systems.SearchTree
-}
+	Trees []treeEntry
+	// Files of the trees, to reload them
+	Files []string
+	// State of the viewer to restore, if any
+	Session *session
+
+	// Name of the tree displayed first
+	TreeName string
+	// Node selected when the tree is displayed, if any
+	SelectNode *uint64
+	Filter     *nodeFilter
 
-type InputTree struct {
-	Nodes []Node `json:"nodes"`
+	// Name of the tree compared with the displayed one, if any
+	CompareWith string
+	// Data key matching the nodes of compared trees, by id when empty
+	MatchKey string
+	// Data key coloring the nodes, if any
+	ColorBy string
 }
 
 type Configuration struct {
 	DebugMode bool
+
+	// Number of parsed trees kept in memory
+	TreeCacheSize int
+	// Parse the next tree of the list in the background when a tree is displayed
+	Prefetch bool
+
+	// Number of views of trees kept alive after switching to another tree, and the memory they
+	// can use, in MB
+	ViewCacheSize   int
+	ViewCacheMemory int
+
+	Layout Layout
 }
 
 var config = Configuration{
-	DebugMode: false,
+	DebugMode:       false,
+	TreeCacheSize:   8,
+	Prefetch:        true,
+	ViewCacheSize:   4,
+	ViewCacheMemory: 1024,
+	Layout:          LayeredLayout,
 }
 
 func main() {
-
-	for _, o := range os.Args[1:] {
-		if o == "--debug" {
-			config.DebugMode = true
-		}
-	}
-
 	log.DefaultLogger = log.Logger{
-		Level: log.DebugLevel,
+		Level:      log.DebugLevel,
 		TimeFormat: "15:04:05",
 		Writer: &log.ConsoleWriter{
 			ColorOutput:    true,
@@ -55,5 +71,14 @@ func main() {
 			EndWithMessage: false,
 		},
 	}
-	runVisu(Input{})
+
+	err := runCommand(os.Args[1:])
+	removeTempFiles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "optimview:", err)
+		if errors.Is(err, errThresholdsExceeded) || errors.Is(err, errInvalidNodes) {
+			os.Exit(1)
+		}
+		os.Exit(2)
+	}
 }
@@ -2,7 +2,7 @@ package main
 
 import (
 	"embed"
-	"sort"
+	"slices"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"github.com/gverger/optimview/graph"
@@ -22,17 +22,35 @@ const (
 	TreeSceneID SceneID = 1
 )
 
-func importFile(events chan<- Event, filename string) {
-	graphs := loadSearchTrees(filename)
-	events <- SwitchSearchTree{graphs: graphs}
+func importFiles(events chan<- Event, filenames ...string) {
+	entries := indexFiles(filenames)
+	events <- SwitchSearchTree{entries: entries, files: filenames}
 }
 
-func computePositionsAsync(events chan<- Event, tree *GraphView) {
-	events <- MoveNodes{positions: computePositions(tree)}
+func computePositionsAsync(events chan<- Event, tree, visible *GraphView) {
+	events <- MoveNodes{tree: tree, positions: computePositions(visible)}
+}
+
+type Layout string
+
+const (
+	LayeredLayout Layout = "layered"
+	TreeLayout    Layout = "tree"
+)
+
+var layouts = []Layout{LayeredLayout, TreeLayout}
+
+func layoutCoordinates(tree *GraphView) map[uint64]graph.Position {
+	switch config.Layout {
+	case TreeLayout:
+		return graph.ComputeTreeCoordinates(*tree)
+	default:
+		return graph.ComputeLayeredCoordinates(*tree)
+	}
 }
 
 func computePositions(tree *GraphView) map[uint64]graph.Position {
-	positions := graph.ComputeLayeredCoordinates(*tree)
+	positions := layoutCoordinates(tree)
 	offset := graph.Position{X: positions[0].X - rl.GetScreenWidth()/2, Y: positions[0].Y - rl.GetScreenHeight()/4}
 	for i, p := range positions {
 		positions[i] = graph.Position{
@@ -49,7 +67,14 @@ type ecosystem struct {
 }
 
 func (a app) loadTree(font rl.Font) ecosystem {
-	tree := a.trees[a.currentTree]
+	tree := a.tree()
+	if config.Prefetch {
+		a.store.Prefetch(int(a.currentTree) + 1)
+	}
+	return newEcosystem(tree, font)
+}
+
+func newEcosystem(tree systems.SearchTree, font rl.Font) ecosystem {
 	positions := computePositions(tree.Tree)
 
 	sys := systems.New(config.DebugMode)
@@ -60,6 +85,7 @@ func (a app) loadTree(font rl.Font) ecosystem {
 	sys.Add(systems.NewTargeter())
 	sys.Add(systems.NewViewport())
 	sys.Add(systems.NewMouseSelector())
+	sys.Add(systems.NewPlacementSelector())
 	sys.Add(systems.NewDrawEdges(font))
 	sys.Add(systems.NewDrawNodes(font, len(tree.Tree.Nodes)))
 	sys.Add(systems.NewNodeDetails(font))
@@ -74,52 +100,63 @@ func (a app) loadTree(font rl.Font) ecosystem {
 }
 
 type app struct {
-	events      chan Event
+	events chan Event
+	// Files of the trees, to reload them
+	files       []string
 	treeNames   []string
-	trees       []systems.SearchTree
+	store       *treeStore
 	shapes      []ShapeDesc
 	currentTree int32
 }
 
+func (a app) tree() systems.SearchTree {
+	return a.treeAt(a.currentTree)
+}
+
+func (a app) treeAt(i int32) systems.SearchTree {
+	return a.store.Displayed(int(i))
+}
+
 var lastOpenFile = ""
 
-func newApp(trees map[string]systems.SearchTree) app {
-	events := make(chan Event, 1)
+// The format of the files is detected from their content, the patterns only help finding them.
+var treeFileFilters = zenity.FileFilters{
+	{Name: "Tree file", Patterns: []string{
+		"*.json", "*.json.gz", "*.json.zst",
+		"*.tar", "*.tar.gz", "*.tgz", "*.tar.zst", "*.tzst",
+		"*.zip",
+	}, CaseFold: true},
+	{Name: "All files", Patterns: []string{"*"}},
+}
 
-	if len(trees) == 0 {
-		files, err := zenity.SelectFileMultiple(
+// newApp loads the trees of the files. The events channel outlives the app: background tasks of a
+// previous app may still send to it, and their stale results are dropped.
+func newApp(events chan Event, files []string, entries []treeEntry) app {
+	if len(entries) == 0 {
+		files = nil
+		selected, err := zenity.SelectFileMultiple(
 			zenity.Title("Search Tree Explorer"),
 			zenity.Filename(lastOpenFile),
-			zenity.FileFilters{
-				{Name: "Tree file", Patterns: []string{"*.json", "*.json.gz", "*.tar.gz", "*.tgz"}, CaseFold: true},
-			})
+			treeFileFilters)
 		if err != nil {
 			log.Error().Err(err).Msg("opening file")
 		}
 		if err == nil {
-			trees = make(map[string]systems.SearchTree)
-			for _, f := range files {
+			for _, f := range selected {
 				lastOpenFile = f
-				filetrees := loadSearchTrees(f)
-				for k, v := range filetrees {
-					trees[k] = v
-				}
+				files = append(files, f)
+				entries = append(entries, indexFiles([]string{f})...)
 			}
 		}
 	}
 
-	inputKeys := Keys(trees)
-	sort.Strings(inputKeys)
-
-	treeArray := make([]systems.SearchTree, len(trees))
-	for i := 0; i < len(treeArray); i++ {
-		treeArray[i] = trees[inputKeys[i]]
-	}
+	store := newTreeStore(entries, config.TreeCacheSize)
 
 	return app{
 		events:      events,
-		treeNames:   inputKeys,
-		trees:       treeArray,
+		files:       files,
+		treeNames:   store.Names(),
+		store:       store,
 		currentTree: 0,
 	}
 }
@@ -144,7 +181,13 @@ func (s Scene) Id() SceneID {
 
 func runVisu(input Input) {
 
-	app := newApp(input.Trees)
+	if input.Session != nil && input.Session.Layout != "" {
+		config.Layout = input.Session.Layout
+	}
+	app := newApp(make(chan Event, 1), input.Files, input.Trees)
+	if input.TreeName != "" {
+		app.currentTree = int32(max(slices.Index(app.treeNames, input.TreeName), 0))
+	}
 
 	// rl.SetConfigFlags(rl.TextureFilterNearestMipLinear)
 
@@ -162,7 +205,31 @@ func runVisu(input Input) {
 
 	rl.SetTargetFPS(60)
 
-	var scene IScene = NewTreeScene(app, font)
+	treeScene := NewTreeScene(app, font)
+	if input.Session != nil {
+		treeScene.engine.applySession(input.Session)
+	}
+	if input.CompareWith != "" {
+		treeScene.engine.compareMode = true
+		treeScene.engine.compareTree = int32(max(slices.Index(app.treeNames, input.CompareWith), 0))
+		treeScene.engine.compareKey = input.MatchKey
+		treeScene.engine.switchTree()
+	}
+	if input.ColorBy != "" {
+		treeScene.engine.colorBy = input.ColorBy
+		treeScene.engine.applyColors()
+	}
+	if input.Filter != nil {
+		treeScene.engine.setFilter(input.Filter)
+	}
+	if input.SelectNode != nil {
+		if !treeScene.engine.ecosystem.sys.HasNode(*input.SelectNode) {
+			log.Warn().Uint64("node", *input.SelectNode).Msg("cannot select node")
+		}
+		treeScene.engine.ecosystem.sys.GoToNode(*input.SelectNode)
+	}
+
+	var scene IScene = treeScene
 	for !rl.WindowShouldClose() {
 		nextSceneID := scene.Update()
 		if nextSceneID == ExitID {
@@ -175,9 +242,22 @@ func runVisu(input Input) {
 type Event any
 
 type MoveNodes struct {
+	// Tree whose nodes move, the event being dropped when another one is displayed
+	tree      *GraphView
 	positions map[uint64]graph.Position
 }
 
 type SwitchSearchTree struct {
-	graphs map[string]systems.SearchTree
+	entries []treeEntry
+	files   []string
+}
+
+type OpenSession struct {
+	entries []treeEntry
+	session *session
+}
+
+type Validated struct {
+	tree       *GraphView
+	validation treeValidation
 }
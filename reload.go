@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/graph"
+)
+
+// viewState is what is displayed before reloading the files, to display it again after.
+type viewState struct {
+	tree        string
+	compareTree string
+	splitTree   string
+	selected    *uint64
+	camera      rl.Camera2D
+}
+
+func (e *treeEngine) currentViewState() viewState {
+	names := e.app.treeNames
+	state := viewState{
+		tree:        names[e.app.currentTree],
+		compareTree: names[min(e.compareTree, int32(len(names)-1))],
+		splitTree:   names[min(e.splitTree, int32(len(names)-1))],
+		camera:      e.ecosystem.sys.Camera(),
+	}
+	if id, ok := e.ecosystem.sys.SelectedNode(); ok {
+		state.selected = &id
+	}
+	return state
+}
+
+// treeIndex returns the index of the tree with that name, or the first one.
+func treeIndex(names []string, name string) int32 {
+	return int32(max(slices.Index(names, name), 0))
+}
+
+// reload displays the trees of the imported files. When the displayed tree is still there, the
+// selection, the camera, the filter and the collapsed subtrees are kept, and the nodes move from
+// their old positions to the new ones.
+func (e *treeEngine) reload(files []string, entries []treeEntry) {
+	e.stopReplay()
+	state := e.currentViewState()
+	previous := e.ecosystem
+
+	e.views.Clear()
+	e.bookmarks = newBookmarkStore()
+	e.validations = make(map[*GraphView]*treeValidation)
+	e.app = newApp(e.app.events, files, entries)
+	e.app.currentTree = treeIndex(e.app.treeNames, state.tree)
+	e.compareTree = treeIndex(e.app.treeNames, state.compareTree)
+	e.splitTree = treeIndex(e.app.treeNames, state.splitTree)
+
+	e.ecosystem = e.loadEcosystem()
+	e.applyColors()
+	e.applyBookmarks()
+	e.applyValidation()
+	e.view = e.currentViewKey()
+	e.menuNode = nil
+	e.stats = nil
+	e.statsErr = nil
+	if e.app.treeNames[e.app.currentTree] == state.tree {
+		e.carryOver(previous, state)
+	} else {
+		e.history = newNavigationHistory()
+		e.allNodes = true
+		e.collapsed = nil
+		if e.filter != nil {
+			e.updateVisibility()
+		}
+	}
+	previous.sys.Close()
+	e.loadSplit()
+}
+
+// carryOver applies the state of the previous ecosystem to the new one. The camera follows the
+// selected node, so that it stays in place on the screen while the others move.
+func (e *treeEngine) carryOver(previous ecosystem, state viewState) {
+	visible := e.hideInvisible()
+
+	// Positions of the visible nodes are computed now, not in the background, to animate the
+	// nodes directly to them
+	var layout map[uint64]graph.Position
+	if len(visible.Nodes) != len(e.displayedTree().Tree.Nodes) {
+		layout = computePositions(visible)
+	}
+	newPosition := func(id uint64) (rl.Vector2, bool) {
+		if layout == nil {
+			return e.ecosystem.sys.NodePosition(id)
+		}
+		p, ok := layout[id]
+		return rl.NewVector2(float32(p.X), float32(p.Y)), ok
+	}
+
+	offset := rl.Vector2{}
+	if state.selected != nil {
+		newPos, okNew := newPosition(*state.selected)
+		oldPos, okOld := previous.sys.NodePosition(*state.selected)
+		if okNew && okOld {
+			offset = rl.Vector2Subtract(newPos, oldPos)
+		}
+	}
+
+	for _, n := range visible.Nodes {
+		e.ecosystem.sys.SamePositions(n.Id, *previous.sys, offset)
+		if p, ok := layout[n.Id]; ok {
+			e.ecosystem.sys.MoveNode(&e.ecosystem.world, n.Id, p.X, p.Y)
+		}
+	}
+
+	camera := state.camera
+	camera.Target = rl.Vector2Add(camera.Target, offset)
+	e.ecosystem.sys.SetCamera(camera)
+	if state.selected != nil {
+		e.ecosystem.sys.SelectNode(*state.selected)
+	}
+}
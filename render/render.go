@@ -0,0 +1,382 @@
+// Package render draws search trees with tdewolff/canvas, on the CPU, without any window or OpenGL
+// context. The drawing mimics the viewer: same layout, node backgrounds, shapes and edges.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/gverger/optimview/graph"
+	"github.com/gverger/optimview/systems"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers"
+)
+
+type Tree = graph.Graph[*systems.DisplayableNode, uint64]
+
+// Sizes in layout units, as in the viewer
+const (
+	NodeSize       = 100
+	nodeBorderSize = 5
+	edgeGap        = 8
+	margin         = 40
+)
+
+type Options struct {
+	// Only draw the subtree of this node, if set
+	Root *uint64
+	// Only draw nodes up to this depth below the root, negative for no limit
+	MaxDepth int
+	// Data key used to color the node backgrounds, if set
+	ColorBy string
+	// Millimeters per layout unit
+	Scale float64
+	// Page size in millimeters. The drawing is split in tiles of this size. Zero for a single page
+	// fitting the whole tree.
+	PageWidth  float64
+	PageHeight float64
+
+	// Computes the node positions of a tree
+	Layout func(tree *Tree) map[uint64]graph.Position
+	// Font used for node labels, no label when empty
+	FontData []byte
+}
+
+// Subtree returns the part of the tree drawn with the options.
+func Subtree(tree *Tree, opts Options) (*Tree, error) {
+	roots := make([]int, 0, 1)
+	if opts.Root != nil {
+		idx, ok := tree.Lookup[*opts.Root]
+		if !ok {
+			return nil, fmt.Errorf("no node with id %d", *opts.Root)
+		}
+		roots = append(roots, idx)
+	} else {
+		for i, p := range tree.ParentIndices() {
+			if p == -1 {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	keep := make(map[int]bool, len(tree.Nodes))
+	level := roots
+	for depth := 0; len(level) > 0 && (opts.MaxDepth < 0 || depth <= opts.MaxDepth); depth++ {
+		next := make([]int, 0)
+		for _, i := range level {
+			if keep[i] {
+				continue
+			}
+			keep[i] = true
+			next = append(next, tree.ChildrenIndices(i)...)
+		}
+		level = next
+	}
+
+	return tree.Subgraph(func(n *systems.DisplayableNode) bool { return keep[tree.Lookup[n.Id]] }), nil
+}
+
+// Page is one tile of the drawing. Row and Col start at 0.
+type Page struct {
+	Row    int
+	Col    int
+	Canvas *canvas.Canvas
+}
+
+type drawer struct {
+	tree      *Tree
+	shapes    []systems.ShapeDefinition
+	positions map[uint64]graph.Position
+	colors    map[uint64]color.RGBA
+	face      *canvas.FontFace
+	scale     float64
+}
+
+// Draw lays the tree out and draws it on one or several pages.
+func Draw(searchTree systems.SearchTree, opts Options) ([]Page, error) {
+	tree, err := Subtree(searchTree.Tree, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(tree.Nodes) == 0 {
+		return nil, fmt.Errorf("nothing to draw")
+	}
+	if opts.Scale <= 0 {
+		opts.Scale = 0.25
+	}
+
+	d := drawer{
+		tree:      tree,
+		shapes:    searchTree.Shapes,
+		positions: opts.Layout(tree),
+		scale:     opts.Scale,
+	}
+	if opts.ColorBy != "" {
+		d.colors = systems.DataColors(tree.Nodes, opts.ColorBy)
+	}
+	if len(opts.FontData) > 0 {
+		family := canvas.NewFontFamily("label")
+		if err := family.LoadFont(opts.FontData, 0, canvas.FontRegular); err != nil {
+			return nil, err
+		}
+		// 12 layout units high
+		d.face = family.Face(12*opts.Scale*ptPerMm, systems.Palette.TextColor)
+	}
+
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, p := range d.positions {
+		minX = min(minX, float64(p.X))
+		minY = min(minY, float64(p.Y))
+		maxX = max(maxX, float64(p.X+NodeSize))
+		maxY = max(maxY, float64(p.Y+NodeSize))
+	}
+	minX -= margin
+	minY -= margin
+	width := (maxX - minX + margin) * opts.Scale
+	height := (maxY - minY + margin) * opts.Scale
+
+	pageWidth, pageHeight := opts.PageWidth, opts.PageHeight
+	if pageWidth <= 0 || pageHeight <= 0 {
+		pageWidth, pageHeight = width, height
+	}
+
+	pages := make([]Page, 0)
+	for row := 0; float64(row)*pageHeight < height; row++ {
+		for col := 0; float64(col)*pageWidth < width; col++ {
+			c := canvas.New(pageWidth, pageHeight)
+			ctx := canvas.NewContext(c)
+			ctx.SetCoordSystem(canvas.CartesianIV)
+			ctx.SetFillColor(canvas.White)
+			ctx.DrawPath(0, 0, canvas.Rectangle(pageWidth, pageHeight))
+
+			// page area, in layout units
+			x0 := minX + float64(col)*pageWidth/opts.Scale
+			y0 := minY + float64(row)*pageHeight/opts.Scale
+			d.draw(ctx, x0, y0, x0+pageWidth/opts.Scale, y0+pageHeight/opts.Scale)
+
+			pages = append(pages, Page{Row: row, Col: col, Canvas: c})
+		}
+	}
+
+	return pages, nil
+}
+
+const ptPerMm = 72.0 / 25.4
+
+// point converts layout coordinates to page coordinates.
+func (d drawer) point(x0, y0, x, y float64) (float64, float64) {
+	return (x - x0) * d.scale, (y - y0) * d.scale
+}
+
+func (d drawer) draw(ctx *canvas.Context, x0, y0, x1, y1 float64) {
+	visible := func(x, y, w, h float64) bool {
+		return x <= x1 && y <= y1 && x+w >= x0 && y+h >= y0
+	}
+
+	ctx.SetStrokeColor(canvas.Gray)
+	ctx.SetStrokeWidth(2 * d.scale)
+	ctx.SetFillColor(canvas.Transparent)
+	for a, dst := range d.tree.Edges {
+		from := d.positions[d.tree.Nodes[a].Id]
+		for b := range dst {
+			to := d.positions[d.tree.Nodes[b].Id]
+			left, right := min(from.X, to.X), max(from.X, to.X)
+			if !visible(float64(left), float64(from.Y), float64(right-left+NodeSize), float64(to.Y-from.Y+NodeSize)) {
+				continue
+			}
+			d.drawEdge(ctx, x0, y0, from, to)
+		}
+	}
+
+	for _, n := range d.tree.Nodes {
+		p := d.positions[n.Id]
+		if !visible(float64(p.X), float64(p.Y), NodeSize, NodeSize) {
+			continue
+		}
+		d.drawNode(ctx, x0, y0, n, p)
+	}
+}
+
+func (d drawer) drawEdge(ctx *canvas.Context, x0, y0 float64, from, to graph.Position) {
+	x1, y1 := d.point(x0, y0, float64(from.X)+NodeSize/2, float64(from.Y)+NodeSize+edgeGap)
+	x2, y2 := d.point(x0, y0, float64(to.X)+NodeSize/2, float64(to.Y)-edgeGap)
+	midY := (y1 + y2) / 2
+
+	path := &canvas.Path{}
+	path.MoveTo(x1, y1)
+	path.LineTo(x1, midY)
+	path.LineTo(x2, midY)
+	path.LineTo(x2, y2)
+	ctx.DrawPath(0, 0, path)
+
+	arrow := &canvas.Path{}
+	arrow.MoveTo(x2, y2)
+	arrow.LineTo(x2+4*d.scale, y2-10*d.scale)
+	arrow.LineTo(x2-4*d.scale, y2-10*d.scale)
+	arrow.Close()
+	ctx.Push()
+	ctx.SetFillColor(canvas.Gray)
+	ctx.DrawPath(0, 0, arrow)
+	ctx.Pop()
+}
+
+func (d drawer) drawNode(ctx *canvas.Context, x0, y0 float64, n *systems.DisplayableNode, p graph.Position) {
+	x, y := d.point(x0, y0, float64(p.X), float64(p.Y))
+	size := NodeSize * d.scale
+
+	background, ok := d.colors[n.Id]
+	if !ok {
+		background = systems.Palette.Background
+	}
+	ctx.Push()
+	ctx.SetStrokeColor(canvas.Transparent)
+	ctx.SetFillColor(background)
+	ctx.DrawPath(x, y, canvas.Rectangle(size, size))
+	ctx.Pop()
+
+	drawPlot(ctx, d.shapes, n.Transform, x, y, size)
+
+	if d.face != nil {
+		// text is placed by its baseline
+		ctx.DrawText(x+2*d.scale, y+2*d.scale+d.face.Metrics().Ascent, canvas.NewTextLine(d.face, fmt.Sprintf("Node %d", n.Id), canvas.Left))
+	}
+}
+
+// drawPlot draws the shapes of a node, scaled and centered in the size x size square at (x, y).
+func drawPlot(ctx *canvas.Context, shapes []systems.ShapeDefinition, transforms []systems.ShapeTransform, x, y, size float64) {
+	if len(transforms) == 0 {
+		return
+	}
+
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, tr := range transforms {
+		def := shapes[tr.Id]
+		minX = min(minX, float64(tr.X+def.MinX))
+		minY = min(minY, float64(tr.Y+def.MinY))
+		maxX = max(maxX, float64(tr.X+def.MaxX))
+		maxY = max(maxY, float64(tr.Y+def.MaxY))
+	}
+
+	// a single point or aligned points keep their size
+	scale := 1.0
+	if dim := max(maxX-minX, maxY-minY); dim > 0 {
+		scale = size * (NodeSize - nodeBorderSize) / NodeSize / dim
+	}
+	// centered in the node, y axis pointing up as in the viewer
+	offsetX := x + (size-scale*(maxX-minX))/2 - scale*minX
+	offsetY := y + (size+scale*(maxY-minY))/2 + scale*minY
+
+	for _, tr := range transforms {
+		for _, s := range shapes[tr.Id].Shapes {
+			drawShape(ctx, s, tr, offsetX, offsetY, scale)
+		}
+	}
+}
+
+// NodePlot draws the shapes of a node alone, on a transparent square page of size millimeters.
+func NodePlot(shapes []systems.ShapeDefinition, n *systems.DisplayableNode, size float64) *canvas.Canvas {
+	c := canvas.New(size, size)
+	ctx := canvas.NewContext(c)
+	ctx.SetCoordSystem(canvas.CartesianIV)
+	drawPlot(ctx, shapes, n.Transform, 0, 0, size)
+	return c
+}
+
+func drawShape(ctx *canvas.Context, s systems.DrawableShape, tr systems.ShapeTransform, offsetX, offsetY, scale float64) {
+	if len(s.Points) == 0 {
+		return
+	}
+	point := func(p systems.Position) (float64, float64) {
+		return offsetX + scale*(p.X+float64(tr.X)), offsetY - scale*(p.Y+float64(tr.Y))
+	}
+
+	path := &canvas.Path{}
+	path.MoveTo(point(s.Points[0]))
+	for _, p := range s.Points[1:] {
+		path.LineTo(point(p))
+	}
+	if !s.Open {
+		path.Close()
+		for _, hole := range s.Holes {
+			if len(hole) == 0 {
+				continue
+			}
+			path.MoveTo(point(hole[0]))
+			for _, p := range hole[1:] {
+				path.LineTo(point(p))
+			}
+			path.Close()
+		}
+	}
+
+	border, fill := s.Colors(tr.Highlighted())
+	ctx.Push()
+	ctx.SetFillRule(canvas.EvenOdd)
+	ctx.SetStrokeColor(border)
+	ctx.SetStrokeWidth(0.3)
+	if s.Open {
+		ctx.SetFillColor(canvas.Transparent)
+	} else {
+		ctx.SetFillColor(fill)
+	}
+	ctx.DrawPath(0, 0, path)
+	ctx.Pop()
+}
+
+// Write writes the pages to filename, the format depending on its extension (png, svg, pdf...).
+// dpmm is the resolution of raster formats.
+// When there are several pages, their row and column are added to the file name.
+func Write(filename string, pages []Page, dpmm float64) error {
+	for _, p := range pages {
+		name := filename
+		if len(pages) > 1 {
+			ext := filepath.Ext(filename)
+			name = fmt.Sprintf("%s-r%d-c%d%s", strings.TrimSuffix(filename, ext), p.Row+1, p.Col+1, ext)
+		}
+		opts := []any{}
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".svg", ".pdf", ".eps", ".ps", ".tex":
+			// vector formats have no resolution
+		default:
+			opts = append(opts, canvas.DPMM(dpmm))
+		}
+		if err := renderers.Write(name, p.Canvas, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PageSize parses a page size: a known format (a4, a3, letter...) or WIDTHxHEIGHT in millimeters.
+// An optional "-landscape" suffix swaps the dimensions.
+func PageSize(s string) (float64, float64, error) {
+	s = strings.ToLower(s)
+	landscape := strings.HasSuffix(s, "-landscape")
+	s = strings.TrimSuffix(s, "-landscape")
+
+	sizes := map[string][2]float64{
+		"a0":     {841, 1189},
+		"a1":     {594, 841},
+		"a2":     {420, 594},
+		"a3":     {297, 420},
+		"a4":     {210, 297},
+		"a5":     {148, 210},
+		"letter": {215.9, 279.4},
+		"legal":  {215.9, 355.6},
+	}
+	size, ok := sizes[s]
+	if !ok {
+		if _, err := fmt.Sscanf(s, "%fx%f", &size[0], &size[1]); err != nil || size[0] <= 0 || size[1] <= 0 {
+			return 0, 0, fmt.Errorf("invalid page size %q", s)
+		}
+	}
+	if landscape {
+		return size[1], size[0], nil
+	}
+	return size[0], size[1], nil
+}
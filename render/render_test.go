@@ -0,0 +1,125 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gverger/optimview/graph"
+	"github.com/gverger/optimview/systems"
+	"github.com/iancoleman/orderedmap"
+)
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		s             string
+		width, height float64
+		wantErr       bool
+	}{
+		{s: "a4", width: 210, height: 297},
+		{s: "A3", width: 297, height: 420},
+		{s: "a4-landscape", width: 297, height: 210},
+		{s: "Letter-Landscape", width: 279.4, height: 215.9},
+		{s: "100x50", width: 100, height: 50},
+		{s: "100.5x50-landscape", width: 50, height: 100.5},
+		{s: "b4", wantErr: true},
+		{s: "100", wantErr: true},
+		{s: "0x50", wantErr: true},
+		{s: "-10x50", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			width, height, err := PageSize(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("PageSize(%q) = %v, %v, want an error", tt.s, width, height)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if width != tt.width || height != tt.height {
+				t.Errorf("PageSize(%q) = %v, %v, want %v, %v", tt.s, width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+// testTree is 0 -> 1 -> 3 -> 5, 0 -> 2 -> 4.
+func testTree() *Tree {
+	tree := graph.NewGraph[*systems.DisplayableNode, uint64](func(n *systems.DisplayableNode) uint64 { return n.Id })
+	for id := range uint64(6) {
+		tree.AddNode(&systems.DisplayableNode{Id: id, Data: orderedmap.New()})
+	}
+	for _, e := range [][2]uint64{{0, 1}, {0, 2}, {1, 3}, {2, 4}, {3, 5}} {
+		tree.AddEdgeId(e[0], e[1])
+	}
+	return tree
+}
+
+func TestSubtree(t *testing.T) {
+	id := func(i uint64) *uint64 { return &i }
+	tests := []struct {
+		name    string
+		opts    Options
+		want    []uint64
+		wantErr bool
+	}{
+		{name: "whole tree", opts: Options{MaxDepth: -1}, want: []uint64{0, 1, 2, 3, 4, 5}},
+		{name: "root only", opts: Options{MaxDepth: 0}, want: []uint64{0}},
+		{name: "max depth", opts: Options{MaxDepth: 2}, want: []uint64{0, 1, 2, 3, 4}},
+		{name: "subtree", opts: Options{Root: id(1), MaxDepth: -1}, want: []uint64{1, 3, 5}},
+		{name: "subtree with max depth", opts: Options{Root: id(1), MaxDepth: 1}, want: []uint64{1, 3}},
+		{name: "leaf", opts: Options{Root: id(4), MaxDepth: -1}, want: []uint64{4}},
+		{name: "unknown root", opts: Options{Root: id(42), MaxDepth: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := Subtree(testTree(), tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Subtree() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]uint64, 0, len(sub.Nodes))
+			for _, n := range sub.Nodes {
+				ids = append(ids, n.Id)
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("Subtree() = %v, want %v", ids, tt.want)
+			}
+			for i, p := range sub.ParentIndices() {
+				if p == -1 && sub.Nodes[i].Id != tt.want[0] {
+					t.Errorf("Subtree() has root %d, want %d", sub.Nodes[i].Id, tt.want[0])
+				}
+			}
+		})
+	}
+}
+
+func TestNodePlotWithoutSize(t *testing.T) {
+	// Bounds left at zero, as for a single point: the plot has no size
+	point := systems.ShapeDefinition{Shapes: []systems.DrawableShape{{Points: []systems.Position{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}}}}
+	n := &systems.DisplayableNode{Id: 1, Transform: []systems.ShapeTransform{{Id: 0, X: 3, Y: 4}}}
+
+	filename := filepath.Join(t.TempDir(), "plot.svg")
+	if err := Write(filename, []Page{{Canvas: NodePlot([]systems.ShapeDefinition{point}, n, 100)}}, 1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svg := string(data); strings.Contains(svg, "NaN") || strings.Contains(svg, "Inf") {
+		t.Errorf("plot of a point has invalid coordinates: %s", svg)
+	}
+}
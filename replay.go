@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/systems"
+)
+
+const replayBarHeight = 36
+
+// Speeds of the replay, in nodes per second
+var replaySpeeds = []float32{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+// replay reveals the nodes of the displayed tree one by one, in the order they were explored.
+type replay struct {
+	active  bool
+	playing bool
+	// Index in replaySpeeds
+	speed int
+	// Number of nodes revealed, the fractional part growing until the next one
+	position float32
+	// Number of nodes revealed in the ecosystem
+	shown int
+	// Nodes in the order they are revealed
+	order []uint64
+	// Data key of the time a node is explored, the order of the ids when empty
+	key     string
+	keyEdit bool
+	// Key of the order
+	orderKey string
+	follow   bool
+	// Nodes kept by the filters and the collapsed subtrees, the only ones revealed
+	visible *GraphView
+}
+
+// replayOrder sorts the nodes by the value of the key, nodes without value coming last. Ties, and
+// every node when key is empty, are in the order of the ids.
+func replayOrder(nodes []*DisplayableNode, key string) []uint64 {
+	type timed struct {
+		id    uint64
+		time  float64
+		timed bool
+	}
+	sorted := make([]timed, 0, len(nodes))
+	for _, n := range nodes {
+		t := timed{id: n.Id}
+		if key != "" {
+			if v, ok := n.Value(key); ok {
+				t.time, t.timed = systems.NumericValue(v)
+			}
+		}
+		sorted = append(sorted, t)
+	}
+	slices.SortFunc(sorted, func(a, b timed) int {
+		if a.timed != b.timed {
+			if a.timed {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(cmp.Compare(a.time, b.time), cmp.Compare(a.id, b.id))
+	})
+
+	order := make([]uint64, 0, len(sorted))
+	for _, t := range sorted {
+		order = append(order, t.id)
+	}
+	return order
+}
+
+// startReplay hides every node, to reveal them from the first one.
+func (e *treeEngine) startReplay() {
+	r := &e.replay
+	r.active = true
+	r.playing = false
+	r.orderKey = strings.TrimSpace(r.key)
+	r.order = replayOrder(e.displayedTree().Tree.Nodes, r.orderKey)
+	r.position = 1
+	r.shown = len(r.order)
+	e.applyReplay()
+}
+
+// stopReplay shows the nodes hidden by the replay.
+func (e *treeEngine) stopReplay() {
+	if !e.replay.active {
+		return
+	}
+	e.replay.active = false
+	e.replay.playing = false
+	e.hideInvisible()
+	e.ecosystem.sys.SetLatest(0, false)
+}
+
+// replayHidden returns the nodes not revealed yet, nil when not replaying.
+func (e *treeEngine) replayHidden() []uint64 {
+	if !e.replay.active {
+		return nil
+	}
+	return e.replay.order[e.replay.shown:]
+}
+
+// applyReplay reveals the nodes up to the position of the replay, or hides the ones after it when
+// going back, and marks the last revealed one.
+func (e *treeEngine) applyReplay() {
+	r := &e.replay
+	count := min(int(r.position), len(r.order))
+	switch {
+	case count > r.shown:
+		// nodes hidden by the filters stay hidden
+		revealed := make([]uint64, 0, count-r.shown)
+		for _, id := range r.order[r.shown:count] {
+			if r.visible.HasNode(&DisplayableNode{Id: id}) {
+				revealed = append(revealed, id)
+			}
+		}
+		r.shown = count
+		e.ecosystem.sys.Show(&e.ecosystem.world, revealed)
+	case count < r.shown:
+		r.shown = count
+		e.hideInvisible()
+	default:
+		return
+	}
+
+	if count == 0 {
+		e.ecosystem.sys.SetLatest(0, false)
+		return
+	}
+	latest := r.order[count-1]
+	e.ecosystem.sys.SetLatest(latest, true)
+	if r.follow {
+		e.ecosystem.sys.CenterOn(latest)
+	}
+}
+
+// updateReplay moves the replay forward by the time of the last frame.
+func (e *treeEngine) updateReplay() {
+	r := &e.replay
+	if !r.active {
+		return
+	}
+	if r.playing {
+		r.position = min(r.position+replaySpeeds[r.speed]*rl.GetFrameTime(), float32(len(r.order)))
+		if int(r.position) == len(r.order) {
+			r.playing = false
+		}
+	}
+	e.applyReplay()
+}
+
+// drawReplayBar draws the controls of the replay at the bottom of the view: play/pause, speed,
+// timeline, order and camera follow. It returns the area of the bar, empty when not replaying.
+func (e *treeEngine) drawReplayBar() rl.Rectangle {
+	r := &e.replay
+	if !r.active {
+		return rl.Rectangle{}
+	}
+	area := e.viewArea()
+	barRec := rl.NewRectangle(area.X, area.Y+area.Height-replayBarHeight-30, area.Width, replayBarHeight)
+	rl.DrawRectangleRec(barRec, rl.NewColor(246, 248, 250, 230))
+	rl.DrawRectangleLinesEx(barRec, 1, rl.NewColor(209, 217, 224, 255))
+
+	height := barRec.Height - 8
+	offsetX := barRec.X + 10
+	y := barRec.Y + 4
+
+	icon := gui.ICON_PLAYER_PLAY
+	if r.playing {
+		icon = gui.ICON_PLAYER_PAUSE
+	}
+	if gui.Button(rl.NewRectangle(offsetX, y, height, height), gui.IconText(icon, "")) {
+		r.playing = !r.playing
+		if r.playing && int(r.position) >= len(r.order) {
+			r.position = 1
+		}
+	}
+	offsetX += height + 10
+
+	if gui.Button(rl.NewRectangle(offsetX, y, height, height), "-") {
+		r.speed = max(r.speed-1, 0)
+	}
+	offsetX += height
+	speedText := fmt.Sprintf("%g nodes/s", replaySpeeds[r.speed])
+	speedSize := navButton("1000 nodes/s")
+	gui.Label(rl.NewRectangle(offsetX+5, y, speedSize.X, height), speedText)
+	offsetX += speedSize.X + 5
+	if gui.Button(rl.NewRectangle(offsetX, y, height, height), "+") {
+		r.speed = min(r.speed+1, len(replaySpeeds)-1)
+	}
+	offsetX += height + 10
+
+	// Right side first, the timeline taking the remaining room
+	rightX := barRec.X + barRec.Width - 10
+	followSize := navButton("follow")
+	rightX -= followSize.X + 16
+	r.follow = gui.CheckBox(rl.NewRectangle(rightX, y+(height-16)/2, 16, 16), "follow", r.follow)
+	rightX -= 10 + 100
+	keyRec := rl.NewRectangle(rightX, y, 100, height)
+	if gui.TextBox(keyRec, &r.key, 32, r.keyEdit) {
+		if r.keyEdit && strings.TrimSpace(r.key) != r.orderKey {
+			e.startReplay()
+		}
+		r.keyEdit = !r.keyEdit
+	}
+	orderSize := navButton("order by")
+	rightX -= orderSize.X
+	gui.Label(rl.NewRectangle(rightX, y, orderSize.X, height), "order by")
+
+	count := min(int(r.position), len(r.order))
+	status := fmt.Sprintf("%d / %d", count, len(r.order))
+	if count > 0 {
+		status += fmt.Sprintf(": node %d", r.order[count-1])
+	}
+	statusSize := navButton("000000 / 000000: node 000000")
+	rightX -= statusSize.X + 10
+	gui.Label(rl.NewRectangle(rightX, y, statusSize.X, height), status)
+
+	sliderRec := rl.NewRectangle(offsetX, y+height/4, max(rightX-10-offsetX, 20), height/2)
+	position := gui.Slider(sliderRec, "", "", r.position, 0, float32(len(r.order)))
+	if position != r.position {
+		r.position = position
+		r.playing = false
+	}
+
+	return barRec
+}
@@ -1,17 +1,11 @@
 package main
 
 import (
-	"archive/tar"
-	"bufio"
-	"compress/gzip"
 	"math"
-	"path"
-	"path/filepath"
 	"strings"
 
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/gverger/optimview/graph"
 	"github.com/gverger/optimview/systems"
@@ -21,75 +15,27 @@ import (
 	"github.com/phuslu/log"
 )
 
-func loadSearchTree(reader io.Reader) systems.SearchTree {
+func loadSearchTree(reader io.Reader) (systems.SearchTree, error) {
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
 	dec := json.NewDecoder(reader)
 
 	var tree Tree
-	MustSucceed(dec.Decode(&tree))
-
-	log.Info().Int("nodes", len(tree.Nodes)).Msg("Tree loaded")
-
-	return systems.SearchTree{
-		Tree:   tree.ToGraph(),
-		Shapes: tree.Shapes(),
+	if err := dec.Decode(&tree); err != nil {
+		return systems.SearchTree{}, err
 	}
-}
-
-func loadTarTrees(filename string) map[string]systems.SearchTree {
-	file := Must(os.Open(filename))
-	defer file.Close()
 
-	log.Info().Str("file", filename).Msg("Opening file")
-
-	trees := make(map[string]systems.SearchTree)
-	gzipReader := Must(gzip.NewReader(file))
-	defer gzipReader.Close()
-	tarReader := tar.NewReader(gzipReader)
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-
-		MustSucceed(err)
-
-		if header.Typeflag == tar.TypeReg && path.Ext(header.Name) == ".json" {
-			log.Info().Str("filename", header.Name).Msg("reading file")
-			trees[header.Name[:len(header.Name)-5]] = loadSearchTree(tarReader)
-		} else {
-			log.Info().Str("filename", header.Name).Msg("skipping non json entry")
-		}
-	}
-	return trees
-}
+	log.Info().Int("nodes", len(tree.Nodes)).Msg("Tree loaded")
 
-func loadSearchTrees(filename string) map[string]systems.SearchTree {
-	if path.Ext(filename) == ".tgz" || strings.HasSuffix(filename, ".tar.gz") {
-		return loadTarTrees(filename)
+	shapes, err := tree.Shapes()
+	if err != nil {
+		return systems.SearchTree{}, err
 	}
-
-	file := Must(os.Open(filename))
-	defer file.Close()
-
-	log.Info().Str("file", filename).Msg("Opening file")
-
-	var reader io.Reader
-	if path.Ext(filename) == ".gz" {
-		gzipReader := Must(gzip.NewReader(file))
-		defer gzipReader.Close()
-		reader = bufio.NewReader(gzipReader)
-	} else {
-		reader = bufio.NewReader(file)
+	searchTree := systems.SearchTree{
+		Tree:   tree.ToGraph(),
+		Shapes: shapes,
 	}
-
-	trees := make(map[string]systems.SearchTree, 1)
-	key := filepath.Base(filename)
-	key = strings.TrimSuffix(key, ".gz")
-	key = strings.TrimSuffix(key, ".json")
-	trees[key] = loadSearchTree(reader)
-	return trees
+	systems.ComputeMetrics(searchTree.Tree, searchTree.Shapes)
+	return searchTree, nil
 }
 
 type Position struct {
@@ -108,6 +54,8 @@ type ShapeDesc struct {
 	FillColor string
 	Shape     []Edge   `json:"Shape"`
 	Holes     [][]Edge `json:"Holes"`
+	// The item of the shape contains the other items, placements exceeding it are reported
+	Container bool `json:"Container,omitempty"`
 }
 
 type ShapePos struct {
@@ -136,16 +84,15 @@ func (t Tree) ToGraph() *GraphView {
 	var index uint64
 	for _, n := range t.Nodes {
 		if n == nil && index == 0 {
-			g.AddNode(&DisplayableNode{Id: 0, Text: "root"})
+			g.AddNode(&DisplayableNode{Id: 0, Text: "root", Data: orderedmap.New()})
 			index++
 			continue
 		}
 		if n == nil {
 			continue
 		}
+		// Placements keep their positions, to compare them between nodes
 		shapeTransforms := make([]ShapeTransform, 0, len(n.Plot))
-		minX := float32(math.MaxFloat32)
-		minY := float32(math.MaxFloat32)
 		for _, p := range n.Plot {
 			shapeTransforms = append(shapeTransforms, ShapeTransform{
 				Id:        p.Id,
@@ -153,15 +100,14 @@ func (t Tree) ToGraph() *GraphView {
 				Y:         p.Y,
 				Highlight: p.FillColor == "green",
 			})
-			minX = min(minX, p.X)
-			minY = min(minY, p.Y)
 		}
-		for i := range shapeTransforms {
-			shapeTransforms[i].X -= minX
-			shapeTransforms[i].Y -= minY
+
+		data := &n.Data
+		if data.Values() == nil {
+			data = orderedmap.New()
 		}
 
-		g.AddNode(&DisplayableNode{Id: n.Id, Text: nodeDetailsText(*n), Transform: shapeTransforms})
+		g.AddNode(&DisplayableNode{Id: n.Id, Text: nodeDetailsText(*n), Data: data, Transform: shapeTransforms})
 		index++
 	}
 
@@ -175,14 +121,16 @@ func (t Tree) ToGraph() *GraphView {
 		}
 		g.AddEdgeId(parent, n.Id)
 	}
+	systems.MarkNewPlacements(g)
 
 	return g
 }
 
-func (t Tree) Shapes() []systems.ShapeDefinition {
+func (t Tree) Shapes() ([]systems.ShapeDefinition, error) {
 	shapes := make([]systems.ShapeDefinition, 0, len(t.Init))
 	for iInit, s := range t.Init {
 		polygons := make([]systems.DrawableShape, 0)
+		container := false
 		minX := float32(math.MaxFloat32)
 		minY := float32(math.MaxFloat32)
 		maxX := float32(-math.MaxFloat32)
@@ -191,7 +139,7 @@ func (t Tree) Shapes() []systems.ShapeDefinition {
 			polygon := make([]systems.Position, 0, len(d.Shape)+1)
 			open := true
 			if len(d.Shape) == 0 {
-				log.Fatal().Int("object index", iInit).Int("shape index", iShape).Msg("shape has no edge")
+				return nil, fmt.Errorf("shape %d of object %d has no edge", iShape, iInit)
 			} else {
 				e := d.Shape[0]
 				polygon = append(polygon, systems.Position{X: float64(e.Start.X), Y: float64(e.Start.Y)})
@@ -208,7 +156,7 @@ func (t Tree) Shapes() []systems.ShapeDefinition {
 
 				for i, e := range shape {
 					if i < len(shape)-1 && e.End != shape[(i+1)%len(shape)].Start {
-						log.Fatal().Interface("shape", shape).Int("index", i).Msg("shape should be closed")
+						return nil, fmt.Errorf("shape %d of object %d should be closed, edge %d is not followed by the next one", iShape, iInit, i)
 					}
 					polygon = append(polygon, systems.Position{X: float64(e.End.X), Y: float64(e.End.Y)})
 					minX = min(minX, e.End.X)
@@ -218,12 +166,13 @@ func (t Tree) Shapes() []systems.ShapeDefinition {
 				}
 			}
 
+			container = container || d.Container
 			shape := systems.DrawableShape{Open: open, Points: polygon, Color: d.FillColor}
 			for _, edges := range d.Holes {
 				hole := make([]systems.Position, 0, len(edges))
 				for i, e := range edges {
 					if e.End.X != edges[(i+1)%len(edges)].Start.X {
-						log.Fatal().Interface("holes", edges).Int("index", i).Msg("edges")
+						return nil, fmt.Errorf("hole of shape %d of object %d should be closed, edge %d is not followed by the next one", iShape, iInit, i)
 					}
 					hole = append(hole, systems.Position{X: float64(e.Start.X), Y: float64(e.Start.Y)})
 				}
@@ -232,14 +181,15 @@ func (t Tree) Shapes() []systems.ShapeDefinition {
 			polygons = append(polygons, shape)
 		}
 		shapes = append(shapes, systems.ShapeDefinition{
-			Shapes: polygons,
-			MinX:   minX,
-			MinY:   minY,
-			MaxX:   maxX,
-			MaxY:   maxY,
+			Shapes:    polygons,
+			MinX:      minX,
+			MinY:      minY,
+			MaxX:      maxX,
+			MaxY:      maxY,
+			Container: container,
 		})
 	}
-	return shapes
+	return shapes, nil
 }
 
 func nodeDetailsText(n TNode) string {
@@ -253,5 +203,5 @@ func nodeDetailsText(n TNode) string {
 		}
 	}
 
-	return fmt.Sprintf(sb.String())
+	return sb.String()
 }
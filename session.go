@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/ncruces/zenity"
+	"github.com/phuslu/log"
+)
+
+const sessionExtension = ".optimview-session"
+
+var sessionFileFilter = zenity.FileFilter{Name: "optimview session", Patterns: []string{"*" + sessionExtension}, CaseFold: true}
+
+// session is the state of the viewer saved in a file, to display the same view later. Files next to
+// the session file are relative to it, so that they can be sent together.
+type session struct {
+	Files  []string `json:"files"`
+	Tree   string   `json:"tree"`
+	Layout Layout   `json:"layout"`
+
+	Camera   sessionCamera `json:"camera"`
+	Selected *uint64       `json:"selected,omitempty"`
+
+	Filter                string   `json:"filter,omitempty"`
+	NodesWithChildrenOnly bool     `json:"nodes_with_children_only,omitempty"`
+	Collapsed             []uint64 `json:"collapsed,omitempty"`
+	ColorBy               string   `json:"color_by,omitempty"`
+	PathKey               string   `json:"path_key,omitempty"`
+
+	Compare *sessionCompare `json:"compare,omitempty"`
+
+	// Bookmarks of the trees, used when the sidecar files are not sent along
+	Bookmarks treeBookmarks `json:"bookmarks,omitempty"`
+}
+
+// sessionCamera is the point of the world at the center of the window, and the zoom. It does not
+// depend on the size of the window.
+type sessionCamera struct {
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Zoom float32 `json:"zoom"`
+}
+
+type sessionCompare struct {
+	Tree     string `json:"tree"`
+	MatchKey string `json:"match_key,omitempty"`
+}
+
+func isSessionFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), sessionExtension)
+}
+
+func readSession(filename string) (*session, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var s session
+	if err := json.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("invalid session %s: %w", filename, err)
+	}
+	if len(s.Files) == 0 {
+		return nil, fmt.Errorf("no file in session %s", filename)
+	}
+	if s.Layout != "" && !slices.Contains(layouts, s.Layout) {
+		return nil, fmt.Errorf("unknown layout %q in session %s", s.Layout, filename)
+	}
+
+	dir := filepath.Dir(filename)
+	for i, f := range s.Files {
+		if !filepath.IsAbs(f) {
+			s.Files[i] = filepath.Join(dir, f)
+		}
+	}
+	return &s, nil
+}
+
+func writeSession(filename string, s session) error {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+	files := make([]string, 0, len(s.Files))
+	for _, f := range s.Files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		// Files out of the directory of the session keep their absolute path
+		if rel, err := filepath.Rel(dir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			abs = rel
+		}
+		files = append(files, filepath.ToSlash(abs))
+	}
+	s.Files = files
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(content, '\n'), 0o644)
+}
+
+// importSession indexes the files of a session in the background, before opening it.
+func importSession(events chan<- Event, filename string) {
+	s, err := readSession(filename)
+	if err != nil {
+		log.Error().Err(err).Msg("cannot open session")
+		return
+	}
+	entries := indexFiles(s.Files)
+	events <- OpenSession{entries: entries, session: s}
+}
+
+// currentSession returns the state of the viewer.
+func (e *treeEngine) currentSession() session {
+	camera := e.ecosystem.sys.Camera()
+	center := rl.GetScreenToWorld2D(windowCenter(), camera)
+	s := session{
+		Files:                 make([]string, 0, len(e.app.files)),
+		Tree:                  e.app.treeNames[e.app.currentTree],
+		Layout:                config.Layout,
+		Camera:                sessionCamera{X: center.X, Y: center.Y, Zoom: camera.Zoom},
+		NodesWithChildrenOnly: !e.allNodes,
+		ColorBy:               strings.TrimSpace(e.colorBy),
+		PathKey:               strings.TrimSpace(e.crumbKey),
+	}
+	for _, f := range e.app.files {
+		if f == stdinFilename {
+			log.Warn().Msg("stdin is not saved in the session")
+			continue
+		}
+		s.Files = append(s.Files, f)
+	}
+	if id, ok := e.ecosystem.sys.SelectedNode(); ok {
+		s.Selected = &id
+	}
+	if e.filter != nil {
+		s.Filter = e.filter.Expr
+	}
+	for id := range e.collapsed {
+		s.Collapsed = append(s.Collapsed, id)
+	}
+	slices.Sort(s.Collapsed)
+	if e.comparison != nil {
+		s.Compare = &sessionCompare{Tree: e.comparison.nameB, MatchKey: e.comparison.matchKey}
+	}
+	s.Bookmarks = make(treeBookmarks)
+	for i, name := range e.app.treeNames {
+		if bookmarks := e.bookmarks.Tree(e.app.store.File(i), name); len(bookmarks) > 0 {
+			s.Bookmarks[name] = bookmarks
+		}
+	}
+	return s
+}
+
+// applySession displays the view of the session. The trees of the session must be loaded.
+func (e *treeEngine) applySession(s *session) {
+	names := e.app.treeNames
+	for name, bookmarks := range s.Bookmarks {
+		if i := slices.Index(names, name); i != -1 {
+			e.bookmarks.Merge(e.app.store.File(i), name, bookmarks)
+		}
+	}
+	e.app.currentTree = treeIndex(names, s.Tree)
+	e.compareMode = s.Compare != nil
+	if s.Compare != nil {
+		e.splitMode = false
+		e.compareTree = treeIndex(names, s.Compare.Tree)
+		e.compareKey = s.Compare.MatchKey
+	}
+	e.colorBy = s.ColorBy
+	e.crumbKey = s.PathKey
+	e.filter = nil
+	e.switchTree()
+
+	if s.Filter != "" {
+		filter, err := parseFilter(s.Filter)
+		if err != nil {
+			log.Warn().Err(err).Msg("ignoring the filter of the session")
+		} else {
+			e.filter = filter
+		}
+	}
+	e.allNodes = !s.NodesWithChildrenOnly
+	e.collapsed = make(map[uint64]bool, len(s.Collapsed))
+	for _, id := range s.Collapsed {
+		e.collapsed[id] = true
+	}
+	e.updateVisibility()
+
+	e.ecosystem.sys.SetCamera(rl.Camera2D{
+		Target: rl.NewVector2(s.Camera.X, s.Camera.Y),
+		Offset: windowCenter(),
+		Zoom:   max(s.Camera.Zoom, 0.0125),
+	})
+	if s.Selected != nil && !e.ecosystem.sys.SelectNode(*s.Selected) {
+		log.Warn().Uint64("node", *s.Selected).Msg("cannot select node")
+	}
+}
+
+// openSession replaces the loaded files by the ones of the session, and displays its view.
+func (e *treeEngine) openSession(event OpenSession) {
+	if event.session.Layout != "" {
+		config.Layout = event.session.Layout
+	}
+	e.stopReplay()
+	e.app = newApp(e.app.events, event.session.Files, event.entries)
+	e.bookmarks = newBookmarkStore()
+	e.validations = make(map[*GraphView]*treeValidation)
+	e.applySession(event.session)
+}
+
+func (e *treeEngine) askSaveSession() {
+	file, err := zenity.SelectFileSave(
+		zenity.Title("Save Session"),
+		zenity.Filename("view"+sessionExtension),
+		zenity.ConfirmOverwrite(),
+		sessionFileFilter)
+	if err != nil {
+		log.Info().Err(err).Msg("save session")
+		return
+	}
+	if !isSessionFile(file) {
+		file += sessionExtension
+	}
+	if err := writeSession(file, e.currentSession()); err != nil {
+		log.Error().Err(err).Str("file", file).Msg("cannot save session")
+		return
+	}
+	log.Info().Str("file", file).Msg("session saved")
+}
+
+func windowCenter() rl.Vector2 {
+	return rl.NewVector2(float32(rl.GetScreenWidth())/2, float32(rl.GetScreenHeight())/2)
+}
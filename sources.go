@@ -0,0 +1,362 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gverger/optimview/systems"
+	"github.com/klauspost/compress/zstd"
+	"github.com/phuslu/log"
+)
+
+// stdinFilename is the file name used on the command line to read the input from stdin.
+const stdinFilename = "-"
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zstdMagic     = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+)
+
+// rawInput is an opened input, before any decompression.
+type rawInput interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
+// inputSource is a file or the standard input. It can be opened several times, so that trees can
+// be parsed long after the input has been indexed.
+type inputSource struct {
+	name string
+	open func() (rawInput, error)
+}
+
+func fileSource(filename string) inputSource {
+	return inputSource{
+		name: filename,
+		open: func() (rawInput, error) { return os.Open(filename) },
+	}
+}
+
+type memoryInput struct {
+	*bytes.Reader
+}
+
+func (memoryInput) Close() error { return nil }
+
+// stdinSource reads the whole standard input, since it cannot be read twice.
+func stdinSource() (inputSource, error) {
+	log.Info().Msg("Reading stdin")
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return inputSource{}, err
+	}
+	return inputSource{
+		name: "stdin",
+		open: func() (rawInput, error) { return memoryInput{bytes.NewReader(data)}, nil },
+	}, nil
+}
+
+// decompressed returns a reader on the uncompressed content of r. The compression is detected from
+// the first bytes, not from the file name.
+func decompressed(r *bufio.Reader) (*bufio.Reader, func(), error) {
+	magic, _ := r.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bufio.NewReader(gzipReader), func() { gzipReader.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bufio.NewReader(zstdReader), zstdReader.Close, nil
+	}
+	return r, func() {}, nil
+}
+
+func isCompressed(r *bufio.Reader) bool {
+	magic, _ := r.Peek(4)
+	return bytes.HasPrefix(magic, gzipMagic) || bytes.HasPrefix(magic, zstdMagic)
+}
+
+func isZip(r *bufio.Reader) bool {
+	magic, _ := r.Peek(4)
+	return bytes.HasPrefix(magic, zipMagic) || bytes.HasPrefix(magic, emptyZipMagic)
+}
+
+func isTar(r *bufio.Reader) bool {
+	header, _ := r.Peek(262)
+	return len(header) == 262 && string(header[257:262]) == "ustar"
+}
+
+// indexSearchTrees lists the trees contained in filename. They are parsed later, when needed.
+func indexSearchTrees(filename string) ([]treeEntry, error) {
+	if filename == stdinFilename {
+		src, err := stdinSource()
+		if err != nil {
+			return nil, err
+		}
+		return indexSource(src)
+	}
+	entries, err := indexSource(fileSource(filename))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for i := range entries {
+		entries[i].file = filename
+	}
+	return entries, nil
+}
+
+// indexFiles lists the trees of the files, leaving out the files that cannot be read.
+func indexFiles(files []string) []treeEntry {
+	entries := make([]treeEntry, 0)
+	for _, f := range files {
+		fileEntries, err := indexSearchTrees(f)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot index file")
+			continue
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries
+}
+
+func indexSource(src inputSource) ([]treeEntry, error) {
+	raw, err := src.open()
+	if err != nil {
+		return nil, err
+	}
+	defer raw.Close()
+
+	log.Info().Str("file", src.name).Msg("Indexing file")
+
+	reader := bufio.NewReader(raw)
+	if isZip(reader) {
+		return indexZipTrees(src, raw)
+	}
+
+	compressed := isCompressed(reader)
+	reader, closeReader, err := decompressed(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader()
+
+	if isTar(reader) && compressed {
+		return indexCompressedTarTrees(reader)
+	}
+	if isTar(reader) {
+		return indexTarTrees(src, reader)
+	}
+
+	return []treeEntry{{
+		name: treeNameFromFile(src.name),
+		load: func() (systems.SearchTree, error) { return loadSingleTree(src) },
+	}}, nil
+}
+
+// treeNameFromFile strips the directory and the known extensions of a file name.
+func treeNameFromFile(filename string) string {
+	name := filepath.Base(filename)
+	for _, ext := range []string{".gz", ".zst", ".json"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// treeNameFromEntry keeps the directories of an archive entry, so that runs stored in
+// subdirectories keep distinct names.
+func treeNameFromEntry(entryName string) string {
+	return strings.TrimSuffix(path.Clean(entryName), ".json")
+}
+
+func loadSingleTree(src inputSource) (systems.SearchTree, error) {
+	raw, err := src.open()
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	defer raw.Close()
+
+	log.Info().Str("file", src.name).Msg("Opening file")
+
+	reader, closeReader, err := decompressed(bufio.NewReader(raw))
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	defer closeReader()
+
+	return loadSearchTree(reader)
+}
+
+// countingReader counts the bytes read, to know where tar entries start in the uncompressed stream.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// indexTarTrees lists the json entries of a tar archive with their offsets, without parsing them.
+func indexTarTrees(src inputSource, reader io.Reader) ([]treeEntry, error) {
+	counter := &countingReader{r: reader}
+	tarReader := tar.NewReader(counter)
+
+	entries := make([]treeEntry, 0)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag == tar.TypeReg && path.Ext(header.Name) == ".json" {
+			offset := counter.n
+			size := header.Size
+			entries = append(entries, treeEntry{
+				name: treeNameFromEntry(header.Name),
+				load: func() (systems.SearchTree, error) { return loadTarEntry(src, offset, size) },
+			})
+		} else {
+			log.Info().Str("filename", header.Name).Msg("skipping non json entry")
+		}
+	}
+	log.Info().Int("trees", len(entries)).Msg("File indexed")
+	return entries, nil
+}
+
+// indexCompressedTarTrees writes the uncompressed archive to a temporary file while indexing it.
+// Compressed streams cannot be seeked: the entries are then read from the copy at their offset,
+// instead of decompressing the archive from its start for each of them.
+func indexCompressedTarTrees(reader io.Reader) ([]treeEntry, error) {
+	f, err := os.CreateTemp("", "optimview-*.tar")
+	if err != nil {
+		return nil, err
+	}
+	out := bufio.NewWriter(f)
+	entries, err := indexTarTrees(fileSource(f.Name()), io.TeeReader(reader, out))
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	addTempFile(f.Name())
+	return entries, nil
+}
+
+// Uncompressed copies of the archives, kept until the program ends since their trees can be parsed
+// at any time
+var tempFiles struct {
+	sync.Mutex
+	names []string
+}
+
+func addTempFile(name string) {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	tempFiles.names = append(tempFiles.names, name)
+}
+
+// removeTempFiles deletes the uncompressed copies of the archives, when the program ends.
+func removeTempFiles() {
+	tempFiles.Lock()
+	defer tempFiles.Unlock()
+	for _, name := range tempFiles.names {
+		if err := os.Remove(name); err != nil {
+			log.Warn().Err(err).Str("file", name).Msg("cannot remove temporary file")
+		}
+	}
+	tempFiles.names = nil
+}
+
+// loadTarEntry parses the tree stored at offset in an uncompressed tar archive.
+func loadTarEntry(src inputSource, offset int64, size int64) (systems.SearchTree, error) {
+	raw, err := src.open()
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	defer raw.Close()
+
+	if _, err := raw.Seek(offset, io.SeekStart); err != nil {
+		return systems.SearchTree{}, err
+	}
+	return loadSearchTree(bufio.NewReader(io.LimitReader(raw, size)))
+}
+
+func indexZipTrees(src inputSource, raw rawInput) ([]treeEntry, error) {
+	size, err := raw.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	zipReader, err := zip.NewReader(raw, size)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]treeEntry, 0)
+	for i, f := range zipReader.File {
+		if f.FileInfo().IsDir() || path.Ext(f.Name) != ".json" {
+			log.Info().Str("filename", f.Name).Msg("skipping non json entry")
+			continue
+		}
+		entries = append(entries, treeEntry{
+			name: treeNameFromEntry(f.Name),
+			load: func() (systems.SearchTree, error) { return loadZipEntry(src, i) },
+		})
+	}
+	log.Info().Int("trees", len(entries)).Msg("File indexed")
+	return entries, nil
+}
+
+func loadZipEntry(src inputSource, index int) (systems.SearchTree, error) {
+	raw, err := src.open()
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	defer raw.Close()
+
+	size, err := raw.Seek(0, io.SeekEnd)
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	zipReader, err := zip.NewReader(raw, size)
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+
+	entry, err := zipReader.File[index].Open()
+	if err != nil {
+		return systems.SearchTree{}, err
+	}
+	defer entry.Close()
+
+	return loadSearchTree(bufio.NewReader(entry))
+}
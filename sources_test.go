@@ -0,0 +1,124 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// testArchive writes a tar archive of trees named a/0.json, a/1.json..., the tree i having i+1
+// nodes below its root, compressed by compress when set.
+func testArchive(t *testing.T, trees int, compress func(io.Writer) io.WriteCloser) string {
+	t.Helper()
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for i := range trees {
+		nodes := "null"
+		for id := range i + 1 {
+			nodes += fmt.Sprintf(`, {"Id": %d, "ParentId": 0, "Plot": [], "Data": {"i": %d}}`, id+1, i)
+		}
+		data := []byte(fmt.Sprintf(`{"Name": "t%d", "Init": [], "Nodes": [%s]}`, i, nodes))
+		if err := tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("a/%d.json", i), Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "a/README", Mode: 0o644, Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "trees.tar")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if compress != nil {
+		cw := compress(f)
+		defer cw.Close()
+		w = cw
+	}
+	if _, err := w.Write(archive.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestIndexTarTrees(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress func(io.Writer) io.WriteCloser
+		// Whether the archive is copied uncompressed
+		copied bool
+	}{
+		{name: "tar"},
+		{name: "gzip", compress: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, copied: true},
+		{name: "zstd", compress: func(w io.Writer) io.WriteCloser {
+			zw, err := zstd.NewWriter(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return zw
+		}, copied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := testArchive(t, 4, tt.compress)
+			entries, err := indexSearchTrees(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			copies := slices.Clone(tempFiles.names)
+			defer removeTempFiles()
+			if got := len(copies) == 1; got != tt.copied {
+				t.Fatalf("uncompressed copies %v, want a copy %v", copies, tt.copied)
+			}
+
+			names := make([]string, 0, len(entries))
+			for _, e := range entries {
+				names = append(names, e.name)
+			}
+			if want := []string{"a/0", "a/1", "a/2", "a/3"}; !slices.Equal(names, want) {
+				t.Fatalf("entries %v, want %v", names, want)
+			}
+
+			// In any order
+			for _, i := range []int{3, 1, 0, 2, 3} {
+				tree, err := entries[i].load()
+				if err != nil {
+					t.Fatalf("entry %d: %v", i, err)
+				}
+				if len(tree.Tree.Nodes) != i+2 {
+					t.Errorf("entry %d has %d nodes, want %d", i, len(tree.Tree.Nodes), i+2)
+				}
+				if entries[i].file != filename {
+					t.Errorf("entry %d file = %q, want %q", i, entries[i].file, filename)
+				}
+			}
+
+			removeTempFiles()
+			for _, c := range copies {
+				if _, err := os.Stat(c); !os.IsNotExist(err) {
+					t.Errorf("copy %s not removed: %v", c, err)
+				}
+			}
+		})
+	}
+}
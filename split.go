@@ -0,0 +1,226 @@
+package main
+
+import (
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// splitView shows a second tree next to the current one, in its own ecosystem.
+type splitView struct {
+	ecosystem ecosystem
+	// Store and index of the tree of the second view, to know when to reload it
+	store *treeStore
+	tree  int32
+
+	// Side under the mouse: 0 for the current tree, 1 for the second one. It keeps the inputs
+	// while a mouse button is down.
+	active int
+
+	// Keys matching the nodes of both sides, by id or by path, and the nodes by key
+	keys  [2]map[uint64]string
+	nodes [2]map[string]uint64
+	// Selected node of both sides at the last frame, to detect a new selection
+	selected [2]*uint64
+}
+
+// loadSplit creates, reloads or closes the second view, following the split mode.
+func (e *treeEngine) loadSplit() {
+	if !e.splitMode {
+		if e.split != nil {
+			e.split.ecosystem.sys.Close()
+			e.split = nil
+		}
+		e.ecosystem.sys.SetScreen(rl.Rectangle{})
+		return
+	}
+
+	e.splitTree = min(e.splitTree, int32(len(e.app.treeNames)-1))
+	if e.split == nil || e.split.store != e.app.store || e.split.tree != e.splitTree {
+		if e.split != nil {
+			e.split.ecosystem.sys.Close()
+		}
+		e.split = &splitView{
+			ecosystem: newEcosystem(e.app.treeAt(e.splitTree), e.font),
+			store:     e.app.store,
+			tree:      e.splitTree,
+		}
+	}
+
+	key := strings.TrimSpace(e.splitKey)
+	for side, tree := range []*GraphView{e.app.tree().Tree, e.app.treeAt(e.splitTree).Tree} {
+		order, keys := matchKeys(tree, key)
+		e.split.keys[side] = make(map[uint64]string, len(order))
+		e.split.nodes[side] = make(map[string]uint64, len(order))
+		for _, i := range order {
+			id := tree.Nodes[i].Id
+			e.split.keys[side][id] = keys[i]
+			if _, ok := e.split.nodes[side][keys[i]]; !ok {
+				e.split.nodes[side][keys[i]] = id
+			}
+		}
+		e.split.selected[side] = nil
+	}
+}
+
+// splitAreas returns the areas of the current tree and of the second one, below the top bar and
+// next to the inspector.
+func (e *treeEngine) splitAreas() [2]rl.Rectangle {
+	area := e.viewArea()
+	top := float32(40)
+	width := area.Width
+	height := area.Height - top
+	if e.stackedSplit {
+		return [2]rl.Rectangle{
+			rl.NewRectangle(area.X, top, width, height/2),
+			rl.NewRectangle(area.X, top+height/2, width, height/2),
+		}
+	}
+	return [2]rl.Rectangle{
+		rl.NewRectangle(area.X, top, width/2, height),
+		rl.NewRectangle(area.X+width/2, top, width/2, height),
+	}
+}
+
+// updateSplit updates both views. Only the view under the mouse gets the inputs; the other one
+// follows its camera when cameras are locked.
+func (e *treeEngine) updateSplit() {
+	s := e.split
+	areas := e.splitAreas()
+	sides := [2]*ecosystem{&e.ecosystem, &s.ecosystem}
+
+	if !rl.IsMouseButtonDown(rl.MouseButtonLeft) && !rl.IsMouseButtonDown(rl.MouseButtonRight) {
+		s.active = 0
+		if rl.CheckCollisionPointRec(rl.GetMousePosition(), areas[1]) {
+			s.active = 1
+		}
+	}
+	active, passive := s.active, 1-s.active
+
+	if e.mouseCaptured {
+		sides[active].sys.CaptureInput()
+	} else {
+		sides[active].sys.ReleaseInput()
+	}
+	sides[passive].sys.CaptureInput()
+	for side, eco := range sides {
+		eco.sys.SetScreen(areas[side])
+	}
+
+	sides[active].sys.Update(&sides[active].world)
+	if e.lockCameras {
+		camera := sides[active].sys.Camera()
+		origin := rl.NewVector2(areas[active].X, areas[active].Y)
+		camera.Offset = rl.Vector2Add(rl.Vector2Subtract(camera.Offset, origin), rl.NewVector2(areas[passive].X, areas[passive].Y))
+		sides[passive].sys.SetCamera(camera)
+	}
+	sides[passive].sys.Update(&sides[passive].world)
+
+	e.syncSelection(sides, active)
+
+	names := [2]string{e.app.treeNames[e.app.currentTree], e.app.treeNames[s.tree]}
+	for side, area := range areas {
+		rl.DrawTextEx(e.font, names[side], rl.NewVector2(area.X+10, area.Y+10), 16, 0, rl.DarkGray)
+	}
+	if e.stackedSplit {
+		rl.DrawLineEx(rl.NewVector2(areas[1].X, areas[1].Y), rl.NewVector2(areas[1].X+areas[1].Width, areas[1].Y), 2, rl.LightGray)
+	} else {
+		rl.DrawLineEx(rl.NewVector2(areas[1].X, areas[1].Y), rl.NewVector2(areas[1].X, areas[1].Y+areas[1].Height), 2, rl.LightGray)
+	}
+}
+
+// syncSelection selects in the passive view the node matching a new selection of the active view.
+// With locked cameras, the node is only selected: the camera already follows the active view.
+func (e *treeEngine) syncSelection(sides [2]*ecosystem, active int) {
+	s := e.split
+	passive := 1 - active
+
+	id, ok := sides[active].sys.SelectedNode()
+	if ok && (s.selected[active] == nil || *s.selected[active] != id) {
+		if other, found := s.nodes[passive][s.keys[active][id]]; found {
+			if e.lockCameras {
+				sides[passive].sys.SelectNode(other)
+			} else {
+				sides[passive].sys.GoToNode(other)
+			}
+		}
+	}
+
+	for side, eco := range sides {
+		s.selected[side] = nil
+		if id, ok := eco.sys.SelectedNode(); ok {
+			s.selected[side] = &id
+		}
+	}
+}
+
+// drawSplitControls draws the controls of the split view in the top bar, from offsetX. It returns
+// the area of the controls.
+func (e *treeEngine) drawSplitControls(offsetX float32, height float32) rl.Rectangle {
+	area := rl.NewRectangle(offsetX, 2, 0, height)
+
+	splitSize := navButton("Split")
+	splitRec := rl.NewRectangle(offsetX, 2, splitSize.X, height)
+	if splitMode := gui.Toggle(splitRec, "Split", e.splitMode); splitMode != e.splitMode {
+		e.splitMode = splitMode
+		if e.splitMode && e.compareMode {
+			e.compareMode = false
+			e.switchTree()
+		} else {
+			e.loadSplit()
+		}
+	}
+	offsetX += splitRec.Width + 10
+	area.Width = offsetX - area.X
+
+	if !e.splitMode {
+		return area
+	}
+
+	withSize := navButton("with")
+	gui.Label(rl.NewRectangle(offsetX, 2, withSize.X, height), "with")
+	offsetX += withSize.X
+
+	dropDownWidth := float32(0)
+	for _, name := range e.app.treeNames {
+		dropDownWidth = max(dropDownWidth, navButton(name).X+20)
+	}
+	dropDownRec := rl.NewRectangle(offsetX, 2, dropDownWidth, height)
+	at := e.splitTree
+	if gui.DropdownBox(dropDownRec, strings.Join(e.app.treeNames, ";"), &e.splitTree, e.splitEditMode) {
+		if e.splitEditMode && at != e.splitTree {
+			e.loadSplit()
+		}
+		e.splitEditMode = !e.splitEditMode
+	}
+	offsetX += dropDownWidth + 10
+
+	syncSize := navButton("sync on")
+	gui.Label(rl.NewRectangle(offsetX, 2, syncSize.X, height), "sync on")
+	offsetX += syncSize.X
+
+	keyRec := rl.NewRectangle(offsetX, 2, 100, height)
+	if gui.TextBox(keyRec, &e.splitKey, 32, e.splitKeyEdit) {
+		if e.splitKeyEdit {
+			e.loadSplit()
+		}
+		e.splitKeyEdit = !e.splitKeyEdit
+	}
+	offsetX += keyRec.Width + 10
+
+	lockSize := navButton("Lock cameras")
+	e.lockCameras = gui.Toggle(rl.NewRectangle(offsetX, 2, lockSize.X, height), "Lock cameras", e.lockCameras)
+	offsetX += lockSize.X + 10
+
+	stackedSize := navButton("Stacked")
+	e.stackedSplit = gui.Toggle(rl.NewRectangle(offsetX, 2, stackedSize.X, height), "Stacked", e.stackedSplit)
+	offsetX += stackedSize.X + 10
+
+	area.Width = offsetX - area.X
+	if e.splitEditMode {
+		// the list of the open dropdown
+		area.Height += float32(len(e.app.treeNames)) * (height + 2)
+	}
+	return area
+}
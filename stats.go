@@ -0,0 +1,266 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gverger/optimview/systems"
+)
+
+// Number of subtrees listed in the statistics
+const largestSubtreesCount = 5
+
+type treeStats struct {
+	Name      string  `json:"name"`
+	Nodes     int     `json:"nodes"`
+	Leaves    int     `json:"leaves"`
+	MaxDepth  int     `json:"max_depth"`
+	MeanDepth float64 `json:"mean_depth"`
+	// Mean number of children of the inner nodes
+	MeanBranching float64 `json:"mean_branching"`
+	// Number of nodes by number of children
+	Branching []int `json:"branching"`
+	// Number of nodes by depth, the roots being at depth 0
+	NodesPerDepth   []int          `json:"nodes_per_depth"`
+	LargestSubtrees []subtreeStats `json:"largest_subtrees"`
+	Data            []dataSummary  `json:"data"`
+	// Statuses of the nodes, when the tree is a branch and bound
+	BranchAndBound *branchAndBoundSummary `json:"branch_and_bound,omitempty"`
+
+	// Node ids by depth, to navigate to them
+	depthNodes [][]uint64
+}
+
+type subtreeStats struct {
+	Root  uint64 `json:"root"`
+	Nodes int    `json:"nodes"`
+}
+
+// dataSummary sums up the numeric values of a data key. Nodes without the key, or with a value that
+// is not a number, are not counted.
+type dataSummary struct {
+	Key    string  `json:"key"`
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+}
+
+// computeTreeStats fails when some nodes are not reached from a root, or reached twice: the nodes
+// form a cycle, or have several parents.
+func computeTreeStats(name string, tree *GraphView) (treeStats, error) {
+	stats := treeStats{
+		Name:            name,
+		Nodes:           len(tree.Nodes),
+		LargestSubtrees: make([]subtreeStats, 0),
+		Data:            make([]dataSummary, 0),
+	}
+	if len(tree.Nodes) == 0 {
+		return stats, nil
+	}
+
+	parents := tree.ParentIndices()
+
+	// Breadth first, so that parents come before their children
+	order := make([]int, 0, len(tree.Nodes))
+	depths := make([]int, len(tree.Nodes))
+	reached := make([]bool, len(tree.Nodes))
+	for i, p := range parents {
+		if p == -1 {
+			order = append(order, i)
+			reached[i] = true
+		}
+	}
+	for k := 0; k < len(order); k++ {
+		i := order[k]
+		if depths[i] == len(stats.NodesPerDepth) {
+			stats.NodesPerDepth = append(stats.NodesPerDepth, 0)
+			stats.depthNodes = append(stats.depthNodes, nil)
+		}
+		stats.NodesPerDepth[depths[i]]++
+		stats.depthNodes[depths[i]] = append(stats.depthNodes[depths[i]], tree.Nodes[i].Id)
+
+		children := tree.ChildrenIndices(i)
+		for len(stats.Branching) <= len(children) {
+			stats.Branching = append(stats.Branching, 0)
+		}
+		stats.Branching[len(children)]++
+
+		for _, c := range children {
+			if reached[c] {
+				return treeStats{}, fmt.Errorf("node %d has several parents", tree.Nodes[c].Id)
+			}
+			reached[c] = true
+			depths[c] = depths[i] + 1
+			order = append(order, c)
+		}
+	}
+	if len(order) != len(tree.Nodes) {
+		return treeStats{}, fmt.Errorf("%d nodes in a cycle, not under a root", len(tree.Nodes)-len(order))
+	}
+
+	totalDepth := 0
+	for _, i := range order {
+		totalDepth += depths[i]
+	}
+	stats.MaxDepth = len(stats.NodesPerDepth) - 1
+	stats.MeanDepth = float64(totalDepth) / float64(len(order))
+	stats.Leaves = stats.Branching[0]
+	if inner := len(order) - stats.Leaves; inner > 0 {
+		roots := stats.NodesPerDepth[0]
+		stats.MeanBranching = float64(len(order)-roots) / float64(inner)
+	}
+
+	sizes := make([]int, len(tree.Nodes))
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		sizes[i]++
+		if parents[i] != -1 {
+			sizes[parents[i]] += sizes[i]
+		}
+	}
+	for i, p := range parents {
+		// the subtree of a root is the whole tree
+		if p != -1 {
+			stats.LargestSubtrees = append(stats.LargestSubtrees, subtreeStats{Root: tree.Nodes[i].Id, Nodes: sizes[i]})
+		}
+	}
+	slices.SortStableFunc(stats.LargestSubtrees, func(a, b subtreeStats) int { return cmp.Compare(b.Nodes, a.Nodes) })
+	stats.LargestSubtrees = stats.LargestSubtrees[:min(largestSubtreesCount, len(stats.LargestSubtrees))]
+
+	stats.Data = dataSummaries(tree.Nodes)
+	stats.BranchAndBound = summarizeBranchAndBound(tree)
+
+	return stats, nil
+}
+
+// loadTreeStats computes the statistics of every tree of the files, sorted by name.
+func loadTreeStats(files []string) ([]treeStats, error) {
+	trees, names, err := loadAllTrees(files)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]treeStats, 0, len(names))
+	for i, tree := range trees {
+		s, err := computeTreeStats(names[i], tree.Tree)
+		if err != nil {
+			return nil, fmt.Errorf("tree %s: %w", names[i], err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// dataSummaries sums up the numeric data keys and metrics, in the order they first appear.
+func dataSummaries(nodes []*DisplayableNode) []dataSummary {
+	keys := make([]string, 0)
+	values := make(map[string][]float64)
+	for _, n := range nodes {
+		for _, key := range n.Keys() {
+			v, _ := n.Value(key)
+			f, ok := systems.NumericValue(v)
+			if !ok {
+				continue
+			}
+			if _, ok := values[key]; !ok {
+				keys = append(keys, key)
+			}
+			values[key] = append(values[key], f)
+		}
+	}
+
+	summaries := make([]dataSummary, 0, len(keys))
+	for _, key := range keys {
+		vs := values[key]
+		slices.Sort(vs)
+		sum := 0.0
+		for _, v := range vs {
+			sum += v
+		}
+		summaries = append(summaries, dataSummary{
+			Key:    key,
+			Count:  len(vs),
+			Min:    vs[0],
+			Max:    vs[len(vs)-1],
+			Mean:   sum / float64(len(vs)),
+			P10:    quantile(vs, 0.1),
+			P25:    quantile(vs, 0.25),
+			Median: quantile(vs, 0.5),
+			P75:    quantile(vs, 0.75),
+			P90:    quantile(vs, 0.9),
+		})
+	}
+	return summaries
+}
+
+// quantile interpolates linearly between the closest ranks of the sorted values, 0 without values.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	low := int(math.Floor(pos))
+	high := min(low+1, len(sorted)-1)
+	return sorted[low] + (pos-float64(low))*(sorted[high]-sorted[low])
+}
+
+// writeStatsTable writes one row per tree, then the details of every tree.
+func writeStatsTable(w io.Writer, stats []treeStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "tree\tnodes\tleaves\tmax depth\tmean depth\tmean branching\t")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f\t%.2f\t\n", s.Name, s.Nodes, s.Leaves, s.MaxDepth, s.MeanDepth, s.MeanBranching)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		fmt.Fprintf(w, "\n== %s\n", s.Name)
+		fmt.Fprintf(w, "nodes per depth: %s\n", joinInts(s.NodesPerDepth))
+		fmt.Fprintf(w, "nodes per number of children: %s\n", joinInts(s.Branching))
+
+		subtrees := make([]string, 0, len(s.LargestSubtrees))
+		for _, sub := range s.LargestSubtrees {
+			subtrees = append(subtrees, fmt.Sprintf("%d: %d nodes", sub.Root, sub.Nodes))
+		}
+		fmt.Fprintf(w, "largest subtrees: %s\n", strings.Join(subtrees, ", "))
+		if bnb := s.BranchAndBound; bnb != nil {
+			fmt.Fprintf(w, "statuses: %s\n", bnb.statusesText())
+			fmt.Fprintf(w, "pruned: %s\n", bnb.Pruned)
+			fmt.Fprintf(w, "best solution: %s\n", bnb.bestText())
+			fmt.Fprintf(w, "root bound: %s\n", bnb.boundText())
+		}
+
+		if len(s.Data) == 0 {
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "key\tcount\tmin\tp10\tp25\tmedian\tp75\tp90\tmax\tmean\t")
+		for _, d := range s.Data {
+			fmt.Fprintf(tw, "%s\t%d\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t\n", d.Key, d.Count, d.Min, d.P10, d.P25, d.Median, d.P75, d.P90, d.Max, d.Mean)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinInts(values []int) string {
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		s = append(s, fmt.Sprint(v))
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	statsPanelWidth      = 340
+	statsLineHeight      = 20
+	statsHistogramHeight = 100
+)
+
+// currentStats returns the statistics of the current tree, computing them the first time.
+func (e *treeEngine) currentStats() (*treeStats, error) {
+	if e.stats == nil && e.statsErr == nil {
+		stats, err := computeTreeStats(e.displayedTreeName(), e.displayedTree().Tree)
+		if err != nil {
+			e.statsErr = err
+			return nil, err
+		}
+		e.stats = &stats
+		e.depthCursors = make([]int, len(stats.NodesPerDepth))
+	}
+	return e.stats, e.statsErr
+}
+
+// drawStatsPanel draws the statistics of the current tree at (x, y). It returns the area of the
+// panel, empty when hidden.
+func (e *treeEngine) drawStatsPanel(x, y float32) rl.Rectangle {
+	if !e.showStats {
+		return rl.Rectangle{}
+	}
+	stats, err := e.currentStats()
+	if err != nil {
+		panelRec := rl.NewRectangle(x, y, statsPanelWidth, float32(24+10+statsLineHeight*2)+10)
+		gui.Panel(panelRec, "Statistics")
+		rl.DrawTextEx(e.font, "Not a tree:", rl.NewVector2(x+10, y+24+10), 16, 0, rl.DarkGray)
+		rl.DrawTextEx(e.font, truncateText(err.Error(), 40), rl.NewVector2(x+10, y+24+10+statsLineHeight), 16, 0, rl.Red)
+		return panelRec
+	}
+
+	lines := []string{
+		fmt.Sprintf("Nodes: %d", stats.Nodes),
+		fmt.Sprintf("Leaves: %d", stats.Leaves),
+		fmt.Sprintf("Depth: max %d, mean %.2f", stats.MaxDepth, stats.MeanDepth),
+		fmt.Sprintf("Branching: mean %.2f", stats.MeanBranching),
+		"Nodes per number of children: " + joinInts(stats.Branching),
+	}
+	subtrees := make([]string, 0, len(stats.LargestSubtrees))
+	for _, sub := range stats.LargestSubtrees {
+		subtrees = append(subtrees, fmt.Sprintf("%d (%d)", sub.Root, sub.Nodes))
+	}
+	lines = append(lines, "Largest subtrees: "+strings.Join(subtrees, ", "))
+	if bnb := stats.BranchAndBound; bnb != nil {
+		lines = append(lines, "Pruned: "+bnb.Pruned.String())
+	}
+
+	dataLines := make([]string, 0, len(stats.Data))
+	for _, d := range stats.Data {
+		dataLines = append(dataLines, fmt.Sprintf("%s: %.4g .. %.4g, mean %.4g, median %.4g", d.Key, d.Min, d.Max, d.Mean, d.Median))
+	}
+
+	height := float32(24+10+statsLineHeight*(len(lines)+1)+statsHistogramHeight+10+statsLineHeight*(len(dataLines)+1)) + 10
+	panelRec := rl.NewRectangle(x, y, statsPanelWidth, height)
+	gui.Panel(panelRec, "Statistics")
+
+	x = panelRec.X + 10
+	y = panelRec.Y + 24 + 10
+	drawLine := func(text string, color rl.Color) {
+		rl.DrawTextEx(e.font, text, rl.NewVector2(x, y), 16, 0, color)
+		y += statsLineHeight
+	}
+
+	for _, line := range lines {
+		drawLine(line, rl.Black)
+	}
+
+	drawLine("Nodes per depth (click to visit)", rl.DarkGray)
+	e.drawDepthHistogram(stats, rl.NewRectangle(x, y, panelRec.Width-20, statsHistogramHeight))
+	y += statsHistogramHeight + 10
+
+	drawLine("Data", rl.DarkGray)
+	for _, line := range dataLines {
+		drawLine(line, rl.Black)
+	}
+
+	return panelRec
+}
+
+// drawDepthHistogram draws a bar per depth. Clicking a bar selects the nodes of that depth in turn.
+func (e *treeEngine) drawDepthHistogram(stats *treeStats, area rl.Rectangle) {
+	rl.DrawRectangleLinesEx(area, 1, rl.LightGray)
+	if len(stats.NodesPerDepth) == 0 {
+		return
+	}
+
+	maxCount := 0
+	for _, count := range stats.NodesPerDepth {
+		maxCount = max(maxCount, count)
+	}
+
+	barWidth := area.Width / float32(len(stats.NodesPerDepth))
+	mouse := rl.GetMousePosition()
+	for depth, count := range stats.NodesPerDepth {
+		barHeight := max(1, (area.Height-2)*float32(count)/float32(maxCount))
+		bar := rl.NewRectangle(area.X+float32(depth)*barWidth, area.Y+area.Height-barHeight, max(1, barWidth-1), barHeight)
+		column := rl.NewRectangle(bar.X, area.Y, barWidth, area.Height)
+
+		color := rl.NewColor(120, 134, 199, 255)
+		if rl.CheckCollisionPointRec(mouse, column) {
+			color = rl.NewColor(45, 51, 107, 255)
+			label := fmt.Sprintf("depth %d: %d nodes", depth, count)
+			rl.DrawTextEx(e.font, label, rl.NewVector2(area.X+5, area.Y+5), 16, 0, rl.Black)
+			if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+				e.goToDepth(depth)
+			}
+		}
+		rl.DrawRectangleRec(bar, color)
+	}
+}
+
+// goToDepth selects the next visible node at that depth.
+func (e *treeEngine) goToDepth(depth int) {
+	ids := e.stats.depthNodes[depth]
+	for range ids {
+		id := ids[e.depthCursors[depth]%len(ids)]
+		e.depthCursors[depth]++
+		if e.ecosystem.sys.HasNode(id) {
+			e.ecosystem.sys.GoToNode(id)
+			return
+		}
+	}
+}
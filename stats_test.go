@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestQuantile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		q      float64
+		want   float64
+	}{
+		{"empty", nil, 0.5, 0},
+		{"single value", []float64{3}, 0.5, 3},
+		{"single value, p0", []float64{3}, 0, 3},
+		{"single value, p1", []float64{3}, 1, 3},
+		{"p0 is the minimum", []float64{1, 2, 4}, 0, 1},
+		{"p1 is the maximum", []float64{1, 2, 4}, 1, 4},
+		{"median of odd count", []float64{1, 2, 4}, 0.5, 2},
+		{"median of even count", []float64{1, 2, 4, 8}, 0.5, 3},
+		{"interpolated", []float64{0, 10}, 0.25, 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quantile(tt.sorted, tt.q); got != tt.want {
+				t.Errorf("quantile(%v, %v) = %v, want %v", tt.sorted, tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeTreeStats(t *testing.T) {
+	tree := testTree(
+		testNode{0, -1, nil},
+		testNode{1, 0, map[string]any{"obj": 4}},
+		testNode{2, 0, map[string]any{"obj": 2}},
+		testNode{3, 0, nil},
+		testNode{4, 1, nil},
+		testNode{5, 1, nil},
+		testNode{6, 4, nil},
+	).Tree
+
+	stats, err := computeTreeStats("run", tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Nodes != 7 || stats.Leaves != 4 || stats.MaxDepth != 3 {
+		t.Errorf("nodes %d, leaves %d, max depth %d, want 7, 4, 3", stats.Nodes, stats.Leaves, stats.MaxDepth)
+	}
+	if want := 10.0 / 7; math.Abs(stats.MeanDepth-want) > 1e-9 {
+		t.Errorf("mean depth = %v, want %v", stats.MeanDepth, want)
+	}
+	if stats.MeanBranching != 2 {
+		t.Errorf("mean branching = %v, want 2", stats.MeanBranching)
+	}
+	if want := []int{1, 3, 2, 1}; !slices.Equal(stats.NodesPerDepth, want) {
+		t.Errorf("nodes per depth = %v, want %v", stats.NodesPerDepth, want)
+	}
+	if want := []int{4, 1, 1, 1}; !slices.Equal(stats.Branching, want) {
+		t.Errorf("branching = %v, want %v", stats.Branching, want)
+	}
+	wantSubtrees := []subtreeStats{{1, 4}, {4, 2}, {2, 1}, {3, 1}, {5, 1}}
+	if !slices.Equal(stats.LargestSubtrees, wantSubtrees) {
+		t.Errorf("largest subtrees = %v, want %v", stats.LargestSubtrees, wantSubtrees)
+	}
+	if len(stats.Data) != 1 || stats.Data[0].Key != "obj" || stats.Data[0].Count != 2 || stats.Data[0].Mean != 3 {
+		t.Errorf("data = %+v, want obj with 2 values of mean 3", stats.Data)
+	}
+}
+
+func TestComputeTreeStatsMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		tree *GraphView
+		// Edges added to the tree
+		edges [][2]uint64
+	}{
+		{
+			name: "no root",
+			tree: testTree(testNode{1, 2, nil}, testNode{2, 1, nil}).Tree,
+		},
+		{
+			name: "cycle beside the root",
+			tree: testTree(testNode{0, -1, nil}, testNode{1, 0, nil}, testNode{2, 3, nil}, testNode{3, 2, nil}).Tree,
+		},
+		{
+			name:  "several parents",
+			tree:  testTree(testNode{0, -1, nil}, testNode{1, 0, nil}, testNode{2, 0, nil}, testNode{3, 1, nil}).Tree,
+			edges: [][2]uint64{{2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.edges {
+				tt.tree.AddEdgeId(e[0], e[1])
+			}
+			if _, err := computeTreeStats("run", tt.tree); err == nil {
+				t.Error("computeTreeStats() succeeded, want an error")
+			}
+		})
+	}
+
+	if stats, err := computeTreeStats("empty", testTree().Tree); err != nil || stats.Nodes != 0 {
+		t.Errorf("computeTreeStats(empty) = %+v, %v", stats, err)
+	}
+}
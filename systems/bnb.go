@@ -0,0 +1,137 @@
+package systems
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gverger/optimview/graph"
+)
+
+// Well-known data keys of branch and bound trees
+const (
+	StatusKey    = "status"
+	BoundKey     = "bound"
+	ObjectiveKey = "objective"
+)
+
+// NodeStatus is the state of a node of a branch and bound, given by its status data key.
+type NodeStatus int
+
+const (
+	NoStatus NodeStatus = iota
+	// OpenStatus is a node not explored yet
+	OpenStatus
+	// BranchedStatus is a node split in children
+	BranchedStatus
+	// PrunedByBoundStatus is a node whose bound is worse than the incumbent
+	PrunedByBoundStatus
+	// InfeasibleStatus is a node without solution
+	InfeasibleStatus
+	// SolutionStatus is a node whose relaxation is a solution
+	SolutionStatus
+	// IncumbentStatus is a solution better than the previous ones
+	IncumbentStatus
+)
+
+// NodeStatuses are the statuses of the nodes, in the order of the legend.
+var NodeStatuses = []NodeStatus{OpenStatus, BranchedStatus, PrunedByBoundStatus, InfeasibleStatus, SolutionStatus, IncumbentStatus}
+
+var statusNames = map[NodeStatus]string{
+	NoStatus:            "",
+	OpenStatus:          "open",
+	BranchedStatus:      "branched",
+	PrunedByBoundStatus: "pruned-by-bound",
+	InfeasibleStatus:    "infeasible",
+	SolutionStatus:      "solution",
+	IncumbentStatus:     "incumbent",
+}
+
+func (s NodeStatus) String() string {
+	return statusNames[s]
+}
+
+// Pruned returns whether the subtree of the node is not explored: the node is infeasible, cannot be
+// better than the incumbent, or is a solution.
+func (s NodeStatus) Pruned() bool {
+	return s == PrunedByBoundStatus || s == InfeasibleStatus || s == SolutionStatus || s == IncumbentStatus
+}
+
+// StatusOf returns the status of the node, NoStatus when it has none or an unknown one. Case and
+// underscores are ignored: "Pruned_By_Bound" is pruned-by-bound.
+func StatusOf(n *DisplayableNode) NodeStatus {
+	v, ok := n.Value(StatusKey)
+	if !ok {
+		return NoStatus
+	}
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(fmt.Sprint(v))), "_", "-")
+	for status, s := range statusNames {
+		if s != "" && s == name {
+			return status
+		}
+	}
+	return NoStatus
+}
+
+// FindBestSolution returns the index of the node of the best solution, -1 when there is none: the
+// incumbent with the largest id, the last one found, or without incumbent the solution with the best
+// objective. The objective is minimized, unless the bound of a root is larger than the objective of
+// a solution.
+func FindBestSolution(tree *graph.Graph[*DisplayableNode, uint64]) int {
+	best := -1
+	for i, n := range tree.Nodes {
+		if StatusOf(n) == IncumbentStatus && (best == -1 || n.Id > tree.Nodes[best].Id) {
+			best = i
+		}
+	}
+	if best != -1 {
+		return best
+	}
+
+	solutions := make([]int, 0)
+	for i, n := range tree.Nodes {
+		if StatusOf(n) == SolutionStatus {
+			solutions = append(solutions, i)
+		}
+	}
+	if len(solutions) == 0 {
+		return -1
+	}
+
+	maximize := false
+	bound, ok := RootBound(tree)
+	if objective, okObjective := numericKey(tree.Nodes[solutions[0]], ObjectiveKey); ok && okObjective {
+		maximize = bound > objective
+	}
+
+	best = solutions[len(solutions)-1]
+	bestObjective, ok := numericKey(tree.Nodes[best], ObjectiveKey)
+	for _, i := range solutions {
+		objective, okObjective := numericKey(tree.Nodes[i], ObjectiveKey)
+		if !okObjective {
+			continue
+		}
+		if !ok || (maximize && objective > bestObjective) || (!maximize && objective < bestObjective) {
+			best, bestObjective, ok = i, objective, true
+		}
+	}
+	return best
+}
+
+// RootBound returns the bound of the first root of the tree, the bound of the whole problem, false
+// when it has none.
+func RootBound(tree *graph.Graph[*DisplayableNode, uint64]) (float64, bool) {
+	for i, p := range tree.ParentIndices() {
+		if p == -1 {
+			return numericKey(tree.Nodes[i], BoundKey)
+		}
+	}
+	return 0, false
+}
+
+func numericKey(n *DisplayableNode, key string) (float64, bool) {
+	v, ok := n.Value(key)
+	if !ok {
+		return 0, false
+	}
+	return NumericValue(v)
+}
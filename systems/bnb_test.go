@@ -0,0 +1,140 @@
+package systems
+
+import (
+	"testing"
+
+	"github.com/gverger/optimview/graph"
+)
+
+// bnbNode is a node of a tree built by bnbTree, the root having no parent (-1).
+type bnbNode struct {
+	id     uint64
+	parent int64
+	data   map[string]any
+}
+
+func bnbTree(nodes ...bnbNode) *graph.Graph[*DisplayableNode, uint64] {
+	tree := graph.NewGraph[*DisplayableNode, uint64](func(n *DisplayableNode) uint64 { return n.Id })
+	for _, n := range nodes {
+		tree.AddNode(stateNode(n.id, nil, n.data))
+	}
+	for _, n := range nodes {
+		if n.parent != -1 {
+			tree.AddEdgeId(uint64(n.parent), n.id)
+		}
+	}
+	return tree
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		value any
+		want  NodeStatus
+	}{
+		{"open", OpenStatus},
+		{"branched", BranchedStatus},
+		{"pruned-by-bound", PrunedByBoundStatus},
+		{"Pruned_By_Bound", PrunedByBoundStatus},
+		{"PRUNED-BY_BOUND", PrunedByBoundStatus},
+		{" infeasible ", InfeasibleStatus},
+		{"Solution", SolutionStatus},
+		{"INCUMBENT", IncumbentStatus},
+		{"pruned by bound", NoStatus},
+		{"prunedbybound", NoStatus},
+		{"", NoStatus},
+		{3, NoStatus},
+		{nil, NoStatus},
+	}
+	for _, tt := range tests {
+		n := stateNode(1, nil, map[string]any{StatusKey: tt.value})
+		if got := StatusOf(n); got != tt.want {
+			t.Errorf("StatusOf(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if got := StatusOf(stateNode(1, nil, nil)); got != NoStatus {
+		t.Errorf("StatusOf(no status) = %v, want %v", got, NoStatus)
+	}
+}
+
+func TestFindBestSolution(t *testing.T) {
+	solution := func(id uint64, objective any) bnbNode {
+		return bnbNode{id, 0, map[string]any{StatusKey: "solution", ObjectiveKey: objective}}
+	}
+	incumbent := func(id uint64, objective any) bnbNode {
+		return bnbNode{id, 0, map[string]any{StatusKey: "incumbent", ObjectiveKey: objective}}
+	}
+	root := func(bound any) bnbNode {
+		return bnbNode{0, -1, map[string]any{StatusKey: "branched", BoundKey: bound}}
+	}
+
+	tests := []struct {
+		name  string
+		nodes []bnbNode
+		// Id of the best solution, -1 for none
+		want int64
+	}{
+		{
+			name:  "no solution",
+			nodes: []bnbNode{root(10), {1, 0, map[string]any{StatusKey: "infeasible"}}},
+			want:  -1,
+		},
+		{
+			name:  "no status",
+			nodes: []bnbNode{{0, -1, nil}, {1, 0, nil}},
+			want:  -1,
+		},
+		{
+			name:  "incumbent with the largest id",
+			nodes: []bnbNode{root(0), incumbent(3, 5), incumbent(7, 4), incumbent(5, 3), solution(2, 1)},
+			want:  7,
+		},
+		{
+			name:  "incumbent whatever the objective",
+			nodes: []bnbNode{root(0), solution(1, 1), incumbent(2, 100)},
+			want:  2,
+		},
+		{
+			name:  "minimized without bound",
+			nodes: []bnbNode{{0, -1, nil}, solution(1, 5), solution(2, 3), solution(3, 4)},
+			want:  2,
+		},
+		{
+			name:  "minimized, bound below the objectives",
+			nodes: []bnbNode{root(1), solution(1, 5), solution(2, 3), solution(3, 4)},
+			want:  2,
+		},
+		{
+			name:  "maximized, bound above the objectives",
+			nodes: []bnbNode{root(10), solution(1, 5), solution(2, 3), solution(3, 4)},
+			want:  1,
+		},
+		{
+			name:  "objectives as strings",
+			nodes: []bnbNode{root("10"), solution(1, "5"), solution(2, "7.5")},
+			want:  2,
+		},
+		{
+			name:  "solution without objective",
+			nodes: []bnbNode{root(0), solution(1, 5), {2, 0, map[string]any{StatusKey: "solution"}}, solution(3, 4)},
+			want:  3,
+		},
+		{
+			name:  "no objective, the last solution",
+			nodes: []bnbNode{root(0), {1, 0, map[string]any{StatusKey: "solution"}}, {2, 0, map[string]any{StatusKey: "Solution"}}},
+			want:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := bnbTree(tt.nodes...)
+			got := int64(-1)
+			if i := FindBestSolution(tree); i != -1 {
+				got = int64(tree.Nodes[i].Id)
+			}
+			if got != tt.want {
+				t.Errorf("FindBestSolution() = node %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
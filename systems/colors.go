@@ -2,7 +2,9 @@ package systems
 
 import (
 	"errors"
+	"fmt"
 	"image/color"
+	"strconv"
 )
 
 type palette struct {
@@ -10,6 +12,19 @@ type palette struct {
 	Hovered    color.RGBA
 	Selected   color.RGBA
 	TextColor  color.RGBA
+	Bookmark   color.RGBA
+	// Nodes placing the selected item, and the ones placing it at the same position
+	SameItem      color.RGBA
+	SamePlacement color.RGBA
+	// Badge of the nodes with overlapping or out of bounds placements
+	Warning color.RGBA
+	// Frame of the nodes with the same state as the selected one
+	Duplicate color.RGBA
+	// Node revealed last by the replay
+	Latest color.RGBA
+	// Borders of the nodes of a branch and bound by status, the incumbent one coloring the path to
+	// the best solution
+	Status [IncumbentStatus + 1]color.RGBA
 }
 
 var Palette = palette{
@@ -17,6 +32,22 @@ var Palette = palette{
 	Hovered:    HexToRGBA(0xA9B5DF),
 	Selected:   HexToRGBA(0x7886C7),
 	TextColor:  HexToRGBA(0x2D336B),
+	Bookmark:   HexToRGBA(0xE4572E),
+
+	SameItem:      HexToRGBA(0xF2A541),
+	SamePlacement: HexToRGBA(0x2E9E5B),
+	Warning:       HexToRGBA(0xF4B400),
+	Duplicate:     HexToRGBA(0x9B5DE5),
+	Latest:        HexToRGBA(0xE63946),
+
+	Status: [...]color.RGBA{
+		OpenStatus:          HexToRGBA(0x4EA8DE),
+		BranchedStatus:      HexToRGBA(0x8D99AE),
+		PrunedByBoundStatus: HexToRGBA(0xF77F00),
+		InfeasibleStatus:    HexToRGBA(0xD62828),
+		SolutionStatus:      HexToRGBA(0x80B918),
+		IncumbentStatus:     HexToRGBA(0x2B9348),
+	},
 }
 
 func HexToRGBA(hex int) color.RGBA {
@@ -64,3 +95,100 @@ func StringToRGBA(s string) (c color.RGBA, err error) {
 	}
 	return
 }
+
+// Colors of the gradient used for numeric data, from the lowest to the highest value
+var (
+	GradientLow  = HexToRGBA(0xFFF7BC)
+	GradientHigh = HexToRGBA(0xD7301F)
+)
+
+// Colors used for data values that are not numbers
+var CategoryColors = []color.RGBA{
+	HexToRGBA(0x4E79A7),
+	HexToRGBA(0xF28E2B),
+	HexToRGBA(0xE15759),
+	HexToRGBA(0x76B7B2),
+	HexToRGBA(0x59A14F),
+	HexToRGBA(0xEDC948),
+	HexToRGBA(0xB07AA1),
+	HexToRGBA(0xFF9DA7),
+	HexToRGBA(0x9C755F),
+	HexToRGBA(0xBAB0AC),
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	lerp := func(x, y uint8) uint8 { return uint8(float64(x) + t*(float64(y)-float64(x))) }
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: lerp(a.A, b.A)}
+}
+
+// NumericValue converts a data value to a number, if it is one.
+func NumericValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case fmt.Stringer:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// DataColors colors the nodes according to the value of a data key: a gradient when all the values
+// are numbers, a palette otherwise. Nodes without the key are not in the result.
+func DataColors(nodes []*DisplayableNode, key string) map[uint64]color.RGBA {
+	colors := make(map[uint64]color.RGBA, len(nodes))
+
+	numeric := true
+	minValue, maxValue := 0.0, 0.0
+	first := true
+	for _, n := range nodes {
+		v, ok := n.Value(key)
+		if !ok {
+			continue
+		}
+		f, ok := NumericValue(v)
+		if !ok {
+			numeric = false
+			break
+		}
+		if first || f < minValue {
+			minValue = f
+		}
+		if first || f > maxValue {
+			maxValue = f
+		}
+		first = false
+	}
+
+	categories := make(map[string]int)
+	for _, n := range nodes {
+		v, ok := n.Value(key)
+		if !ok {
+			continue
+		}
+		if numeric {
+			f, _ := NumericValue(v)
+			t := 0.5
+			if maxValue > minValue {
+				t = (f - minValue) / (maxValue - minValue)
+			}
+			colors[n.Id] = lerpColor(GradientLow, GradientHigh, t)
+			continue
+		}
+
+		category := fmt.Sprint(v)
+		idx, ok := categories[category]
+		if !ok {
+			idx = len(categories)
+			categories[category] = idx
+		}
+		colors[n.Id] = CategoryColors[idx%len(CategoryColors)]
+	}
+
+	return colors
+}
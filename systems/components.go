@@ -29,10 +29,24 @@ type Node struct {
 	midX  float32
 	midY  float32
 
-	color  rl.Color
-	Title  string
-	Text   string
-	hidden bool
+	color rl.Color
+	Title string
+	Text  string
+	// Data changed from the parent, one change per line
+	Changes    string
+	hidden     bool
+	collapsed  bool
+	bookmarked bool
+	// Whether some placements of the node overlap or exceed the container
+	invalid bool
+	// Whether the node has the same state as the selected node
+	duplicate bool
+	// Whether the node is the last one revealed by the replay
+	latest bool
+	// Status of the node in a branch and bound
+	status NodeStatus
+	// Whether the node places the item of the selected placement
+	placementMatch int
 
 	ShapeTransforms []ShapeTransform
 	rendered        bool
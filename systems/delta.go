@@ -0,0 +1,104 @@
+package systems
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/gverger/optimview/graph"
+)
+
+// placementTolerance is the distance under which two placements of an item are at the same position.
+const placementTolerance = 1e-4
+
+// DataChange is a data key whose value differs between a node and its parent.
+type DataChange struct {
+	Key      string
+	Old, New any
+	// Added when the parent has no value for the key, Removed when the node has none
+	Added, Removed bool
+}
+
+func (c DataChange) String() string {
+	switch {
+	case c.Added:
+		return fmt.Sprintf("%s: + %v", c.Key, c.New)
+	case c.Removed:
+		return fmt.Sprintf("%s: - %v", c.Key, c.Old)
+	}
+	return fmt.Sprintf("%s: %v -> %v", c.Key, c.Old, c.New)
+}
+
+// DataChanges returns the data keys of the node whose values differ from the parent, in the order of
+// the node, then the keys removed, in the order of the parent.
+func DataChanges(parent, node *DisplayableNode) []DataChange {
+	changes := make([]DataChange, 0)
+	if node.Data != nil {
+		for _, key := range node.Data.Keys() {
+			value, _ := node.Data.Get(key)
+			old, ok := parent.Value(key)
+			if !ok {
+				changes = append(changes, DataChange{Key: key, New: value, Added: true})
+			} else if !reflect.DeepEqual(old, value) {
+				changes = append(changes, DataChange{Key: key, Old: old, New: value})
+			}
+		}
+	}
+	if parent.Data != nil {
+		for _, key := range parent.Data.Keys() {
+			if _, ok := node.Value(key); !ok {
+				old, _ := parent.Data.Get(key)
+				changes = append(changes, DataChange{Key: key, Old: old, Removed: true})
+			}
+		}
+	}
+	return changes
+}
+
+// ChangesText returns the changes, one per line.
+func ChangesText(changes []DataChange) string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PlacementKey identifies an item placed at a position, rounded to the placement tolerance.
+type PlacementKey struct {
+	Id   int
+	X, Y int64
+}
+
+// Key returns the item and the rounded position of the placement.
+func (t ShapeTransform) Key() PlacementKey {
+	return PlacementKey{
+		Id: t.Id,
+		X:  int64(math.Round(float64(t.X) / placementTolerance)),
+		Y:  int64(math.Round(float64(t.Y) / placementTolerance)),
+	}
+}
+
+// MarkNewPlacements flags the placements of the nodes that are not in their parent, so that they
+// are highlighted. Placements of the roots are not new.
+func MarkNewPlacements(tree *graph.Graph[*DisplayableNode, uint64]) {
+	parents := tree.ParentIndices()
+	for i, n := range tree.Nodes {
+		if parents[i] == -1 {
+			continue
+		}
+		// each placement of the parent matches at most one placement of the node
+		inParent := make(map[PlacementKey]int, len(tree.Nodes[parents[i]].Transform))
+		for _, tr := range tree.Nodes[parents[i]].Transform {
+			inParent[tr.Key()]++
+		}
+		for t, tr := range n.Transform {
+			key := tr.Key()
+			n.Transform[t].New = inParent[key] == 0
+			if inParent[key] > 0 {
+				inParent[key]--
+			}
+		}
+	}
+}
@@ -19,6 +19,8 @@ type DrawEdges struct {
 	font         rl.Font
 	filter       *ecs.Filter2[Edge, VisibleElement]
 	mapNodes     *ecs.Map2[Position, Node]
+	parent       *ecs.Map1[Parent]
+	visible      *ecs.Map1[VisibleElement]
 	visibleWorld ecs.Resource[VisibleWorld]
 	camera       ecs.Resource[CameraHandler]
 
@@ -28,6 +30,7 @@ type DrawEdges struct {
 
 	debug         ecs.Resource[DebugBoard]
 	selected      ecs.Resource[NodeSelection]
+	best          ecs.Resource[BestSolution]
 	boundingBoxes ecs.Resource[SubTreeBoundingBoxes]
 }
 
@@ -38,6 +41,8 @@ func (d *DrawEdges) Close() {
 func (d *DrawEdges) Initialize(w *ecs.World) {
 	d.filter = ecs.NewFilter2[Edge, VisibleElement](w)
 	d.mapNodes = ecs.NewMap2[Position, Node](w)
+	d.parent = ecs.NewMap1[Parent](w)
+	d.visible = ecs.NewMap1[VisibleElement](w)
 	d.visibleWorld = ecs.NewResource[VisibleWorld](w)
 	d.camera = ecs.NewResource[CameraHandler](w)
 
@@ -47,6 +52,7 @@ func (d *DrawEdges) Initialize(w *ecs.World) {
 
 	d.debug = ecs.NewResource[DebugBoard](w)
 	d.selected = ecs.NewResource[NodeSelection](w)
+	d.best = ecs.NewResource[BestSolution](w)
 
 	d.boundingBoxes = ecs.NewResource[SubTreeBoundingBoxes](w)
 }
@@ -146,8 +152,58 @@ func (d *DrawEdges) Update(ctx context.Context, w *ecs.World) {
 		p, n := rootQ.Get()
 		d.drawLevel(ctx, w, *d.visibleWorld.Get(), p, n, rootQ.Entity())
 	}
+	d.drawBestPath()
+	d.drawSelectedPath()
 
 	rl.EndMode2D()
 }
 
+// drawBestPath highlights the edges from the root to the best solution of a branch and bound.
+func (d *DrawEdges) drawBestPath() {
+	if !d.best.Has() {
+		return
+	}
+	best := d.best.Get().Node
+	if best.IsZero() || !d.visible.HasAll(best) {
+		return
+	}
+	d.drawPath(best, Palette.Status[IncumbentStatus])
+}
+
+// drawSelectedPath highlights the edges from the root to the selected node, over the other edges.
+func (d *DrawEdges) drawSelectedPath() {
+	selection := d.selected.Get()
+	if !selection.HasSelected() || !d.visible.HasAll(selection.Selected) {
+		return
+	}
+	d.drawPath(selection.Selected, Palette.Selected)
+}
+
+// drawPath draws the edges from the root to the node, thicker than the other edges.
+func (d *DrawEdges) drawPath(e ecs.Entity, color rl.Color) {
+	thickness := float32(EdgeThickness * 2)
+	for {
+		parent := d.parent.Get(e)
+		if parent == nil || !d.visible.HasAll(parent.parent) {
+			return
+		}
+		p1, from := d.mapNodes.Get(parent.parent)
+		p2, to := d.mapNodes.Get(e)
+
+		x1 := float32(p1.X + from.SizeX/2)
+		y1 := float32(p1.Y + from.SizeY + 8)
+		x2 := float32(p2.X + to.SizeX/2)
+		y2 := float32(p2.Y - 8)
+		cy := (y1 + y2) / 2
+
+		rl.DrawLineEx(rl.NewVector2(x1, y1), rl.NewVector2(x1, cy), thickness, color)
+		rl.DrawLineEx(rl.NewVector2(min(x1, x2)-thickness/2, cy), rl.NewVector2(max(x1, x2)+thickness/2, cy), thickness, color)
+		rl.DrawLineEx(rl.NewVector2(x2, cy), rl.NewVector2(x2, y2-8), thickness, color)
+		rl.DrawTriangle(rl.NewVector2(x2, y2-8), rl.NewVector2(x2, y2), rl.NewVector2(x2+5, y2-11), color)
+		rl.DrawTriangle(rl.NewVector2(x2, y2), rl.NewVector2(x2, y2-8), rl.NewVector2(x2-5, y2-11), color)
+
+		e = parent.parent
+	}
+}
+
 var _ System = &DrawEdges{}
@@ -24,10 +24,15 @@ type DrawNodes struct {
 	shapes       []ShapeDefinition
 	nodeTextures graphics.TextureArray
 
+	// When exporting, every shape is drawn from its polygons, and nothing is rendered in the node
+	// textures: raylib cannot nest texture modes.
+	exporting bool
+
 	filter       *ecs.Filter3[Position, Node, VisibleElement]
 	visibleWorld ecs.Resource[VisibleWorld]
 	camera       ecs.Resource[CameraHandler]
 	selection    ecs.Resource[NodeSelection]
+	screen       ecs.Resource[Screen]
 
 	debug ecs.Resource[DebugBoard]
 }
@@ -42,6 +47,18 @@ func (d *DrawNodes) Close() {
 	}
 }
 
+// textureMemory returns the size of the node and shape textures, in bytes.
+func (d *DrawNodes) textureMemory() int64 {
+	total := int64(0)
+	for _, t := range d.nodeTextures.Textures {
+		total += 4 * int64(t.Texture.Width) * int64(t.Texture.Height)
+	}
+	for _, s := range d.shapes {
+		total += 4 * int64(s.Texture.Texture.Width) * int64(s.Texture.Texture.Height)
+	}
+	return total
+}
+
 const (
 	NodeTextureSize = 100 // Nodes are 100x100
 
@@ -53,6 +70,7 @@ func (d *DrawNodes) Initialize(w *ecs.World) {
 	d.visibleWorld = ecs.NewResource[VisibleWorld](w)
 	d.camera = ecs.NewResource[CameraHandler](w)
 	d.selection = ecs.NewResource[NodeSelection](w)
+	d.screen = ecs.NewResource[Screen](w)
 	d.debug = ecs.NewResource[DebugBoard](w)
 
 	shapes := ecs.NewResource[[]ShapeDefinition](w)
@@ -147,12 +165,49 @@ func (d *DrawNodes) Update(ctx context.Context, w *ecs.World) {
 
 	toRender := make([]func(), 0)
 	toRenderLater := make([]func(), 0)
+	collapsed := make([]Position, 0)
+	bookmarked := make([]Position, 0)
+	invalid := make([]Position, 0)
+	matches := make([]rl.Rectangle, 0)
+	matchColors := make([]rl.Color, 0)
+	statuses := make([]rl.Rectangle, 0)
+	statusColors := make([]rl.Color, 0)
 	for query.Next() {
 		pos, n, _ := query.Get()
+		if n.status != NoStatus {
+			statuses = append(statuses, rl.NewRectangle(float32(pos.X), float32(pos.Y), float32(n.SizeX), float32(n.SizeY)))
+			statusColors = append(statusColors, Palette.Status[n.status])
+		}
+		if n.placementMatch != noPlacementMatch {
+			matches = append(matches, rl.NewRectangle(float32(pos.X)-6, float32(pos.Y)-6, float32(n.SizeX)+12, float32(n.SizeY)+12))
+			color := Palette.SameItem
+			if n.placementMatch == samePlacement {
+				color = Palette.SamePlacement
+			}
+			matchColors = append(matchColors, color)
+		}
+		if n.duplicate {
+			// outside of the frame of the placement matches
+			matches = append(matches, rl.NewRectangle(float32(pos.X)-12, float32(pos.Y)-12, float32(n.SizeX)+24, float32(n.SizeY)+24))
+			matchColors = append(matchColors, Palette.Duplicate)
+		}
+		if n.latest {
+			matches = append(matches, rl.NewRectangle(float32(pos.X)-18, float32(pos.Y)-18, float32(n.SizeX)+36, float32(n.SizeY)+36))
+			matchColors = append(matchColors, Palette.Latest)
+		}
+		if n.collapsed {
+			collapsed = append(collapsed, Position{X: pos.X + n.SizeX/2, Y: pos.Y + n.SizeY})
+		}
+		if n.bookmarked {
+			bookmarked = append(bookmarked, Position{X: pos.X + n.SizeX, Y: pos.Y})
+		}
+		if n.invalid {
+			invalid = append(invalid, Position{X: pos.X, Y: pos.Y})
+		}
 
 		if pos.X > visible.MaxX || pos.Y > visible.MaxY || pos.X+n.SizeX < visible.X || pos.Y+n.SizeY < visible.Y {
 			// render node texture if there is still time
-			if !n.rendered {
+			if !n.rendered && !d.exporting {
 				toRenderLater = append(toRenderLater, func() {
 					d.renderNodeInTexture(n)
 				})
@@ -160,7 +215,7 @@ func (d *DrawNodes) Update(ctx context.Context, w *ecs.World) {
 			continue
 		}
 
-		nodeColor := Palette.Background
+		nodeColor := n.color
 		if hovered == query.Entity() {
 			nodeColor = Palette.Hovered
 		}
@@ -181,13 +236,13 @@ func (d *DrawNodes) Update(ctx context.Context, w *ecs.World) {
 		default:
 		}
 
-		if n.SizeX*n.SizeY < visibleArea/40 && n.rendered {
+		if !d.exporting && n.SizeX*n.SizeY < visibleArea/40 && n.rendered {
 			d.drawOnTexture(n, pos)
 			continue
 		}
 
 		drawFast := false
-		if n.SizeX*n.SizeY < visibleArea/10 {
+		if !d.exporting && n.SizeX*n.SizeY < visibleArea/10 {
 			drawFast = true
 		}
 
@@ -203,18 +258,18 @@ func (d *DrawNodes) Update(ctx context.Context, w *ecs.World) {
 		for _, tr := range n.ShapeTransforms {
 			shapeList := d.shapes[tr.Id]
 
-			if drawFast && !tr.Highlight {
+			if drawFast && !tr.Highlighted() {
 				d.drawShapeFromTexture(n.scale, tr, pos, n.midX, shapeList, reverseY, n.midY)
 			} else {
 				offsetX := n.midX + n.scale*tr.X + float32(pos.X)
 				offsetY := n.midY + reverseY*n.scale*tr.Y + float32(pos.Y)
 
 				for _, s := range shapeList.Shapes {
-					renderShape(s, tr.Highlight, offsetX, offsetY, n.scale, reverseY*n.scale)
+					renderShape(s, tr.Highlighted(), offsetX, offsetY, n.scale, reverseY*n.scale)
 				}
 			}
 		}
-		if !n.rendered {
+		if !n.rendered && !d.exporting {
 			// We don't want to draw in the texture here since we are in the middle of a Mode2D
 			// We delay the call then
 			toRender = append(toRender, func() {
@@ -223,8 +278,51 @@ func (d *DrawNodes) Update(ctx context.Context, w *ecs.World) {
 		}
 	}
 
+	// The status of a branch and bound node is the color of its border
+	for i, rec := range statuses {
+		rl.DrawRectangleLinesEx(rec, 5, statusColors[i])
+	}
+
+	// Nodes placing the selected item, with the state of the selected node, or revealed last by the
+	// replay are framed
+	for i, rec := range matches {
+		rl.DrawRectangleLinesEx(rec, 4, matchColors[i])
+	}
+
+	// A collapsed subtree is a "+" below its root
+	for _, p := range collapsed {
+		center := rl.NewVector2(float32(p.X), float32(p.Y)+12)
+		rl.DrawCircleV(center, 9, Palette.Selected)
+		rl.DrawLineEx(rl.NewVector2(center.X-5, center.Y), rl.NewVector2(center.X+5, center.Y), 2, rl.White)
+		rl.DrawLineEx(rl.NewVector2(center.X, center.Y-5), rl.NewVector2(center.X, center.Y+5), 2, rl.White)
+	}
+
+	// A bookmark is a flag on the top right corner
+	for _, p := range bookmarked {
+		pole := rl.NewVector2(float32(p.X)-4, float32(p.Y)-20)
+		rl.DrawLineEx(pole, rl.NewVector2(pole.X, float32(p.Y)+2), 2, Palette.TextColor)
+		rl.DrawTriangle(pole, rl.NewVector2(pole.X-14, pole.Y+5), rl.NewVector2(pole.X, pole.Y+10), Palette.Bookmark)
+	}
+
+	// Overlapping or out of bounds placements are a warning sign on the top left corner
+	for _, p := range invalid {
+		top := rl.NewVector2(float32(p.X)+4, float32(p.Y)-22)
+		left, right := rl.NewVector2(top.X-13, top.Y+22), rl.NewVector2(top.X+13, top.Y+22)
+		rl.DrawTriangle(top, left, right, Palette.Warning)
+		rl.DrawTriangleLines(top, left, right, Palette.TextColor)
+		rl.DrawLineEx(rl.NewVector2(top.X, top.Y+7), rl.NewVector2(top.X, top.Y+15), 3, Palette.TextColor)
+		rl.DrawCircleV(rl.NewVector2(top.X, top.Y+18.5), 1.5, Palette.TextColor)
+	}
+
 	rl.EndMode2D()
 
+	// The scissor of a screen area would clip the rendering in the node textures
+	screen := d.screen.Get()
+	if screen.Clipped() {
+		rl.EndScissorMode()
+		defer rl.BeginScissorMode(int32(screen.Area.X), int32(screen.Area.Y), int32(screen.Area.Width), int32(screen.Area.Height))
+	}
+
 	for _, renderNode := range toRender {
 		renderNode()
 	}
@@ -235,7 +333,8 @@ func (d *DrawNodes) Update(ctx context.Context, w *ecs.World) {
 			msg = fmt.Sprintf("pre-rendering %d nodes...", len(toRenderLater))
 		}
 		textSize := rl.MeasureTextEx(d.font, msg, 16, 0)
-		rl.DrawTextEx(d.font, msg, rl.NewVector2(float32(rl.GetScreenWidth())-textSize.X, float32(rl.GetScreenHeight())-textSize.Y), 16, 0, rl.Gray)
+		area := screen.Rect()
+		rl.DrawTextEx(d.font, msg, rl.NewVector2(area.X+area.Width-textSize.X, area.Y+area.Height-textSize.Y), 16, 0, rl.Gray)
 		for _, renderNode := range toRenderLater {
 			if ctx.Err() != nil {
 				break
@@ -271,9 +370,9 @@ func (s *DrawNodes) renderNodeInTexture(n *Node) {
 		}
 
 		color := rl.White
-		if tr.Highlight {
+		if tr.Highlighted() {
 			for _, s := range shapeList.Shapes {
-				renderShape(s, tr.Highlight, rec.X+x-n.scale*shapeList.MinX, rec.Y+y+n.scale*shapeList.MaxY, n.scale, reverseY*n.scale)
+				renderShape(s, tr.Highlighted(), rec.X+x-n.scale*shapeList.MinX, rec.Y+y+n.scale*shapeList.MaxY, n.scale, reverseY*n.scale)
 			}
 		} else {
 			rl.DrawTexturePro(shapeList.Texture.Texture,
@@ -320,7 +419,7 @@ var shapeColors = map[string]HighlightableShapeColor{
 	},
 }
 
-func renderShape(s DrawableShape, highlight bool, offsetX, offsetY, scaleX, scaleY float32) {
+func (s DrawableShape) shapeColor(highlight bool) ShapeColor {
 	col, ok := shapeColors[s.Color]
 	if !ok {
 		color, err := StringToRGBA(s.Color)
@@ -334,10 +433,20 @@ func renderShape(s DrawableShape, highlight bool, offsetX, offsetY, scaleX, scal
 		shapeColors[s.Color] = col
 	}
 
-	color := col.normal
 	if highlight {
-		color = col.highlighted
+		return col.highlighted
 	}
+	return col.normal
+}
+
+// Colors returns the border and fill colors of the shape, as drawn in the viewer.
+func (s DrawableShape) Colors(highlight bool) (border color.RGBA, fill color.RGBA) {
+	col := s.shapeColor(highlight)
+	return col.border, col.fill
+}
+
+func renderShape(s DrawableShape, highlight bool, offsetX, offsetY, scaleX, scaleY float32) {
+	color := s.shapeColor(highlight)
 
 	scaled := func(x float64, y float64) rl.Vector2 {
 		return rl.NewVector2(scaleX*float32(x)+offsetX, scaleY*float32(y)+offsetY)
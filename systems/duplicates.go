@@ -0,0 +1,67 @@
+package systems
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"github.com/gverger/optimview/graph"
+)
+
+// StateHash returns a hash of the state of a node: its placements, whatever their order, at the
+// placement tolerance, and the values of the data keys. Nodes with the same state have the same
+// hash.
+func StateHash(n *DisplayableNode, keys []string) uint64 {
+	placements := make([]PlacementKey, 0, len(n.Transform))
+	for _, tr := range n.Transform {
+		placements = append(placements, tr.Key())
+	}
+	slices.SortFunc(placements, func(a, b PlacementKey) int {
+		return cmp.Or(cmp.Compare(a.Id, b.Id), cmp.Compare(a.X, b.X), cmp.Compare(a.Y, b.Y))
+	})
+
+	h := fnv.New64a()
+	buf := make([]byte, 0, 24)
+	for _, p := range placements {
+		buf = binary.LittleEndian.AppendUint64(buf[:0], uint64(p.Id))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p.X))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p.Y))
+		h.Write(buf)
+	}
+	for _, key := range keys {
+		// the separator tells a missing key from an empty value
+		if v, ok := n.Value(key); ok {
+			fmt.Fprintf(h, "\x00%s=%v", key, v)
+		} else {
+			fmt.Fprintf(h, "\x00%s", key)
+		}
+	}
+	return h.Sum64()
+}
+
+// DuplicateGroups returns the hash of every node, and the groups of nodes with the same hash, largest
+// first. Nodes of a group are in the order of the tree.
+func DuplicateGroups(tree *graph.Graph[*DisplayableNode, uint64], keys []string) (map[uint64]uint64, [][]uint64) {
+	hashes := make(map[uint64]uint64, len(tree.Nodes))
+	byHash := make(map[uint64][]uint64)
+	order := make([]uint64, 0)
+	for _, n := range tree.Nodes {
+		h := StateHash(n, keys)
+		hashes[n.Id] = h
+		if _, ok := byHash[h]; !ok {
+			order = append(order, h)
+		}
+		byHash[h] = append(byHash[h], n.Id)
+	}
+
+	groups := make([][]uint64, 0)
+	for _, h := range order {
+		if len(byHash[h]) > 1 {
+			groups = append(groups, byHash[h])
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b []uint64) int { return cmp.Compare(len(b), len(a)) })
+	return hashes, groups
+}
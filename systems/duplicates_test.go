@@ -0,0 +1,121 @@
+package systems
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gverger/optimview/graph"
+	"github.com/iancoleman/orderedmap"
+)
+
+func stateNode(id uint64, placements []ShapeTransform, data map[string]any) *DisplayableNode {
+	values := orderedmap.New()
+	for k, v := range data {
+		values.Set(k, v)
+	}
+	return &DisplayableNode{Id: id, Transform: placements, Data: values}
+}
+
+func TestStateHash(t *testing.T) {
+	a := ShapeTransform{Id: 1, X: 0, Y: 0}
+	b := ShapeTransform{Id: 2, X: 4, Y: 0}
+	tests := []struct {
+		name string
+		x, y *DisplayableNode
+		keys []string
+		same bool
+	}{
+		{
+			name: "placements in another order",
+			x:    stateNode(1, []ShapeTransform{a, b}, nil),
+			y:    stateNode(2, []ShapeTransform{b, a}, nil),
+			same: true,
+		},
+		{
+			name: "placements within the tolerance",
+			x:    stateNode(1, []ShapeTransform{{Id: 1, X: 1, Y: 1}}, nil),
+			y:    stateNode(2, []ShapeTransform{{Id: 1, X: 1.00001, Y: 1}}, nil),
+			same: true,
+		},
+		{
+			name: "another position",
+			x:    stateNode(1, []ShapeTransform{a}, nil),
+			y:    stateNode(2, []ShapeTransform{{Id: 1, X: 1, Y: 0}}, nil),
+		},
+		{
+			name: "another item",
+			x:    stateNode(1, []ShapeTransform{a}, nil),
+			y:    stateNode(2, []ShapeTransform{{Id: 2, X: 0, Y: 0}}, nil),
+		},
+		{
+			name: "an item placed twice",
+			x:    stateNode(1, []ShapeTransform{a}, nil),
+			y:    stateNode(2, []ShapeTransform{a, a}, nil),
+		},
+		{
+			name: "other data, not compared",
+			x:    stateNode(1, []ShapeTransform{a}, map[string]any{"obj": 1}),
+			y:    stateNode(2, []ShapeTransform{a}, map[string]any{"obj": 2}),
+			same: true,
+		},
+		{
+			name: "same data",
+			x:    stateNode(1, []ShapeTransform{a}, map[string]any{"obj": 1, "depth": 3}),
+			y:    stateNode(2, []ShapeTransform{a}, map[string]any{"obj": 1, "depth": 4}),
+			keys: []string{"obj"},
+			same: true,
+		},
+		{
+			name: "other data",
+			x:    stateNode(1, []ShapeTransform{a}, map[string]any{"obj": 1}),
+			y:    stateNode(2, []ShapeTransform{a}, map[string]any{"obj": 2}),
+			keys: []string{"obj"},
+		},
+		{
+			name: "missing key and empty value",
+			x:    stateNode(1, []ShapeTransform{a}, nil),
+			y:    stateNode(2, []ShapeTransform{a}, map[string]any{"obj": ""}),
+			keys: []string{"obj"},
+		},
+		{
+			name: "missing keys",
+			x:    stateNode(1, []ShapeTransform{a}, nil),
+			y:    stateNode(2, []ShapeTransform{a}, nil),
+			keys: []string{"obj"},
+			same: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := StateHash(tt.x, tt.keys) == StateHash(tt.y, tt.keys); same != tt.same {
+				t.Errorf("same hash = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestDuplicateGroups(t *testing.T) {
+	a := ShapeTransform{Id: 1, X: 0, Y: 0}
+	b := ShapeTransform{Id: 2, X: 4, Y: 0}
+	tree := graph.NewGraph[*DisplayableNode, uint64](func(n *DisplayableNode) uint64 { return n.Id })
+	for _, n := range []*DisplayableNode{
+		stateNode(0, nil, nil),
+		stateNode(1, []ShapeTransform{a}, nil),
+		stateNode(2, []ShapeTransform{b}, nil),
+		stateNode(3, []ShapeTransform{a, b}, nil),
+		stateNode(4, []ShapeTransform{b, a}, nil),
+		stateNode(5, []ShapeTransform{a}, nil),
+		stateNode(6, []ShapeTransform{a, b}, nil),
+	} {
+		tree.AddNode(n)
+	}
+
+	hashes, groups := DuplicateGroups(tree, nil)
+	want := [][]uint64{{3, 4, 6}, {1, 5}}
+	if !slices.EqualFunc(groups, want, slices.Equal) {
+		t.Errorf("DuplicateGroups() = %v, want %v", groups, want)
+	}
+	if len(hashes) != len(tree.Nodes) || hashes[3] != hashes[6] || hashes[1] == hashes[2] {
+		t.Errorf("DuplicateGroups() hashes = %v", hashes)
+	}
+}
@@ -32,10 +32,16 @@ func (c *Initializer) Initialize(w *ecs.World) {
 	end := ecs.NewMap2[Parent, ChildOf](w)
 
 	nodeLookup := make(map[uint64]ecs.Entity, 0)
+	nodeIds := make(map[ecs.Entity]uint64, 0)
 
 	graph := c.tree.Tree
+	parents := graph.ParentIndices()
 
 	for i, n := range graph.Nodes {
+		changes := ""
+		if parents[i] != -1 {
+			changes = ChangesText(DataChanges(graph.Nodes[parents[i]], n))
+		}
 		pos := c.initialPositions[graph.NodeID(n)]
 		e := nodes.NewEntity(
 			&Position{
@@ -45,9 +51,11 @@ func (c *Initializer) Initialize(w *ecs.World) {
 				// Y: float64(n.XY[1]),
 			},
 			&Node{
-				color:           rl.Gray,
+				color:           Palette.Background,
 				Title:           fmt.Sprintf("Node %v", n.Id),
 				Text:            n.Text,
+				Changes:         changes,
+				status:          StatusOf(n),
 				SizeX:           100,
 				SizeY:           100,
 				ShapeTransforms: n.Transform,
@@ -68,6 +76,7 @@ func (c *Initializer) Initialize(w *ecs.World) {
 			},
 		)
 		nodeLookup[n.Id] = e
+		nodeIds[e] = n.Id
 		grid.AddEntity(e, GridCoords(pos.X, pos.Y))
 	}
 
@@ -85,6 +94,12 @@ func (c *Initializer) Initialize(w *ecs.World) {
 		}
 	}
 
+	best := ecs.NewResource[BestSolution](w)
+	best.Add(&BestSolution{})
+	if i := FindBestSolution(graph); i != -1 {
+		best.Get().Node = nodeLookup[graph.Nodes[i].Id]
+	}
+
 	shapes := ecs.NewResource[[]ShapeDefinition](w)
 	shapes.Add(&c.tree.Shapes)
 
@@ -95,6 +110,7 @@ func (c *Initializer) Initialize(w *ecs.World) {
 	mappings := ecs.NewResource[Mappings](w)
 	mappings.Add(&Mappings{
 		nodeLookup: nodeLookup,
+		nodeIds:    nodeIds,
 	})
 
 	camera := ecs.NewResource[CameraHandler](w)
@@ -70,6 +70,11 @@ func (s *Inputs) Update(ctx context.Context, w *ecs.World) {
 
 		Space: rl.IsKeyPressed(rl.KeySpace),
 	}
+
+	// Alt with the arrows goes back and forward in the history of the selection
+	if rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt) {
+		input.KeyPressed = Keyboard{Space: input.KeyPressed.Space}
+	}
 }
 
 var _ System = &Inputs{}
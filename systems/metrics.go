@@ -0,0 +1,98 @@
+package systems
+
+import (
+	"math"
+
+	"github.com/gverger/optimview/graph"
+	"github.com/iancoleman/orderedmap"
+)
+
+// Metrics computed from the placements of the nodes, available as data keys unless the solver gives
+// the same keys.
+const (
+	// PlacedItemsMetric is the number of placed items, containers excluded
+	PlacedItemsMetric = "placed_items"
+	// PlacedAreaMetric is the area of the placed items, holes excluded
+	PlacedAreaMetric = "placed_area"
+	// BoundingBoxUtilizationMetric is the placed area over the area of the bounding box of the items
+	BoundingBoxUtilizationMetric = "bbox_utilization"
+	// XExtentMetric is the width used by the items, from the left of the containers if any, as the
+	// length of a strip packing
+	XExtentMetric = "x_extent"
+)
+
+// MetricKeys are the keys of the metrics, in the order they are shown.
+var MetricKeys = []string{PlacedItemsMetric, PlacedAreaMetric, BoundingBoxUtilizationMetric, XExtentMetric}
+
+// ComputeMetrics sets the metrics of the placements of every node. Trees without shapes have none;
+// nodes placing no item have no bounding box utilization nor extent.
+func ComputeMetrics(tree *graph.Graph[*DisplayableNode, uint64], shapes []ShapeDefinition) {
+	if len(shapes) == 0 {
+		return
+	}
+	areas := make([]float64, len(shapes))
+	// left of the containers that are not placed, being at the origin
+	containersLeft := math.Inf(1)
+	for i, def := range shapes {
+		for _, s := range def.Shapes {
+			areas[i] += ShapeArea(s)
+		}
+		if def.Container {
+			containersLeft = min(containersLeft, float64(def.MinX))
+		}
+	}
+
+	for _, n := range tree.Nodes {
+		metrics := orderedmap.New()
+		items, area := 0, 0.0
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		left := math.Inf(1)
+		for _, tr := range n.Transform {
+			def := shapes[tr.Id]
+			if def.Container {
+				left = min(left, float64(tr.X+def.MinX))
+				continue
+			}
+			items++
+			area += areas[tr.Id]
+			minX, minY = min(minX, float64(tr.X+def.MinX)), min(minY, float64(tr.Y+def.MinY))
+			maxX, maxY = max(maxX, float64(tr.X+def.MaxX)), max(maxY, float64(tr.Y+def.MaxY))
+		}
+		metrics.Set(PlacedItemsMetric, items)
+		metrics.Set(PlacedAreaMetric, area)
+		if items > 0 {
+			if box := (maxX - minX) * (maxY - minY); box > 0 {
+				metrics.Set(BoundingBoxUtilizationMetric, area/box)
+			}
+			if math.IsInf(left, 1) {
+				left = min(containersLeft, minX)
+			}
+			metrics.Set(XExtentMetric, maxX-left)
+		}
+		n.Metrics = metrics
+	}
+}
+
+// ShapeArea returns the area of a closed shape, without its holes (shoelace formula). Open shapes
+// have no area.
+func ShapeArea(s DrawableShape) float64 {
+	if s.Open {
+		return 0
+	}
+	area := math.Abs(shoelace(s.Points))
+	for _, hole := range s.Holes {
+		area -= math.Abs(shoelace(hole))
+	}
+	return max(area, 0)
+}
+
+// shoelace returns the signed area of the polygon.
+func shoelace(points []Position) float64 {
+	area := 0.0
+	for i := range points {
+		a, b := points[i], points[(i+1)%len(points)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
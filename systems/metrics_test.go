@@ -0,0 +1,86 @@
+package systems
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gverger/optimview/graph"
+)
+
+func TestShapeArea(t *testing.T) {
+	square := []Position{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	tests := []struct {
+		name  string
+		shape DrawableShape
+		want  float64
+	}{
+		{"square", DrawableShape{Points: square}, 16},
+		{"clockwise", DrawableShape{Points: []Position{{0, 0}, {0, 4}, {4, 4}, {4, 0}}}, 16},
+		{"triangle", DrawableShape{Points: []Position{{0, 0}, {4, 0}, {0, 3}}}, 6},
+		{"with a hole", DrawableShape{Points: square, Holes: [][]Position{{{1, 1}, {2, 1}, {2, 2}, {1, 2}}}}, 15},
+		{"open", DrawableShape{Open: true, Points: square}, 0},
+		{"no point", DrawableShape{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShapeArea(tt.shape); got != tt.want {
+				t.Errorf("ShapeArea() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeMetrics(t *testing.T) {
+	shapes := []ShapeDefinition{rectangle(10, 10, true), rectangle(4, 4, false), rectangle(2, 6, false)}
+	tests := []struct {
+		name       string
+		placements []ShapeTransform
+		want       map[string]float64
+	}{
+		{
+			name: "no placement",
+			want: map[string]float64{PlacedItemsMetric: 0, PlacedAreaMetric: 0},
+		},
+		{
+			name:       "single item",
+			placements: []ShapeTransform{{Id: 1, X: 0, Y: 0}},
+			want: map[string]float64{
+				PlacedItemsMetric: 1, PlacedAreaMetric: 16, BoundingBoxUtilizationMetric: 1, XExtentMetric: 4,
+			},
+		},
+		{
+			name:       "items with a gap",
+			placements: []ShapeTransform{{Id: 1, X: 0, Y: 0}, {Id: 2, X: 6, Y: 0}},
+			want: map[string]float64{
+				PlacedItemsMetric: 2, PlacedAreaMetric: 28, BoundingBoxUtilizationMetric: 28.0 / 48, XExtentMetric: 8,
+			},
+		},
+		{
+			name:       "extent from the left of the container",
+			placements: []ShapeTransform{{Id: 0, X: -2, Y: 0}, {Id: 2, X: 1, Y: 0}},
+			want: map[string]float64{
+				PlacedItemsMetric: 1, PlacedAreaMetric: 12, BoundingBoxUtilizationMetric: 1, XExtentMetric: 5,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := graph.NewGraph[*DisplayableNode, uint64](func(n *DisplayableNode) uint64 { return n.Id })
+			n := &DisplayableNode{Id: 0, Transform: tt.placements}
+			tree.AddNode(n)
+			ComputeMetrics(tree, shapes)
+
+			for _, key := range MetricKeys {
+				v, ok := n.Value(key)
+				want, wanted := tt.want[key]
+				if ok != wanted {
+					t.Errorf("%s: got %v, want %v", key, v, want)
+					continue
+				}
+				if got, _ := NumericValue(v); ok && math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"github.com/gverger/optimview/graph"
+	"github.com/iancoleman/orderedmap"
 	"github.com/osuushi/triangulate"
 	"github.com/phuslu/log"
 	"github.com/tchayen/triangolatte"
@@ -15,15 +16,54 @@ type ShapeTransform struct {
 	X         float32
 	Y         float32
 	Highlight bool
+	// New when the parent has no placement of the item at this position
+	New bool
+}
+
+// Highlighted returns whether the placement is drawn highlighted: asked by the solver, or new.
+func (t ShapeTransform) Highlighted() bool {
+	return t.Highlight || t.New
 }
 
 type DisplayableNode struct {
 	Id   uint64
 	Text string
+	Data *orderedmap.OrderedMap
+	// Metrics computed from the placements, see MetricKeys
+	Metrics *orderedmap.OrderedMap
 
 	Transform []ShapeTransform
 }
 
+// Value returns the value of a data key of the node, or of a metric when the data has no such key.
+func (n *DisplayableNode) Value(key string) (any, bool) {
+	if n.Data != nil {
+		if v, ok := n.Data.Get(key); ok {
+			return v, true
+		}
+	}
+	if n.Metrics != nil {
+		return n.Metrics.Get(key)
+	}
+	return nil, false
+}
+
+// Keys returns the data keys of the node, then its metrics not given by the data.
+func (n *DisplayableNode) Keys() []string {
+	keys := make([]string, 0)
+	if n.Data != nil {
+		keys = append(keys, n.Data.Keys()...)
+	}
+	if n.Metrics != nil {
+		for _, key := range n.Metrics.Keys() {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
 type DrawableShape struct {
 	Color  string
 	Open   bool
@@ -99,6 +139,8 @@ type ShapeDefinition struct {
 	MinY   float32
 	MaxX   float32
 	MaxY   float32
+	// Container of the other items, e.g. the bin of a packing
+	Container bool
 
 	Texture  rl.RenderTexture2D
 	rendered bool
@@ -2,6 +2,7 @@ package systems
 
 import (
 	"context"
+	"fmt"
 
 	gui "github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -18,8 +19,11 @@ type NodeDetails struct {
 	nodes  *ecs.Map3[Position, Node, VisibleElement]
 	input  ecs.Resource[Input]
 	camera ecs.Resource[CameraHandler]
+	screen ecs.Resource[Screen]
+	shapes []ShapeDefinition
 
-	selection ecs.Resource[NodeSelection]
+	selection  ecs.Resource[NodeSelection]
+	placements ecs.Resource[PlacementSelection]
 }
 
 // Close implements System.
@@ -31,41 +35,94 @@ func (n *NodeDetails) Initialize(w *ecs.World) {
 	n.nodes = ecs.NewMap3[Position, Node, VisibleElement](w)
 	n.input = ecs.NewResource[Input](w)
 	n.camera = ecs.NewResource[CameraHandler](w)
+	n.screen = ecs.NewResource[Screen](w)
 	n.selection = ecs.NewResource[NodeSelection](w)
+	n.placements = ecs.NewResource[PlacementSelection](w)
+	shapes := ecs.NewResource[[]ShapeDefinition](w)
+	n.shapes = *shapes.Get()
 }
 
 // Update implements System.
 func (n *NodeDetails) Update(ctx context.Context, w *ecs.World) {
+	placements := n.placements.Get()
+	if placements.Selected != nil {
+		n.displayPlacementMatches(placements)
+	}
+
 	selection := n.selection.Get()
-	if selection.HasHovered() {
-		pos, node, _ := n.nodes.Get(selection.Hovered)
-		n.displayDetails(node, pos)
+	if placements.HasHovered() && n.nodes.HasAll(placements.Node) {
+		pos, node, _ := n.nodes.Get(placements.Node)
+		n.displayPlacement(node, pos, placements)
+	} else if selection.HasHovered() {
+		_, node, _ := n.nodes.Get(selection.Hovered)
+		changes := ""
+		if node.Changes != "" {
+			changes = "changed from parent:\n" + node.Changes
+		}
+		n.displayDetails(node.Title, node.Text, changes)
 	}
 }
 
-func (n *NodeDetails) displayDetails(hoveredNode *Node, pos *Position) {
-	txtDims := rl.MeasureTextEx(n.font, hoveredNode.Text, 32, 0)
+// displayPlacement outlines the placement under the mouse, and describes it.
+func (n *NodeDetails) displayPlacement(node *Node, pos *Position, placements *PlacementSelection) {
+	tr := node.ShapeTransforms[placements.Placement]
+	rl.BeginMode2D(*n.camera.Get().Camera)
+	outlinePlacement(n.shapes[tr.Id],
+		float32(pos.X)+node.midX+node.scale*tr.X, float32(pos.Y)+node.midY+reverseY*node.scale*tr.Y,
+		node.scale, reverseY*node.scale, 3/n.camera.Get().Camera.Zoom, Palette.Bookmark)
+	rl.EndMode2D()
+
+	text := PlacementText(n.shapes, node.ShapeTransforms, placements.Placement, placements.Shape)
+	n.displayDetails(node.Title, text, "click to find the item in the tree")
+}
+
+// displayPlacementMatches tells how many nodes place the selected item, at the bottom of the screen.
+func (n *NodeDetails) displayPlacementMatches(placements *PlacementSelection) {
+	screen := n.screen.Get().Rect()
+	text := fmt.Sprintf("item %d at (%g, %g): placed in %d nodes, %d at this position",
+		placements.Selected.Id, placements.Selected.X, placements.Selected.Y, placements.SameItem, placements.SamePosition)
+	size := rl.MeasureTextEx(n.font, text, 16, 0)
+	position := rl.NewVector2(screen.X+screen.Width/2-size.X/2, screen.Y+screen.Height-size.Y-30)
+	rl.DrawRectangleRec(rl.NewRectangle(position.X-10, position.Y-5, size.X+20, size.Y+10), rl.Fade(rl.White, 0.8))
+	rl.DrawTextEx(n.font, text, position, 16, 0, Palette.TextColor)
+}
+
+// displayDetails draws a tooltip next to the mouse, with a text and an optional smaller text below.
+func (n *NodeDetails) displayDetails(title, text, changes string) {
+	txtDims := rl.MeasureTextEx(n.font, text, 32, 0)
+	changesDims := rl.MeasureTextEx(n.font, changes, 24, 0)
+	textHeight := txtDims.Y
+	if changes != "" {
+		txtDims.X = max(txtDims.X, changesDims.X)
+		txtDims.Y += 10 + changesDims.Y
+	}
 
 	mouse := n.input.Get().Mouse.OnScreen
 	mousePosition := rl.Vector2{X: float32(mouse.X), Y: float32(mouse.Y)}
 
+	screen := n.screen.Get().Rect()
+	right := screen.X + screen.Width - 10
+
 	distX := float32(50)
 
 	rightmostPointX := mousePosition.X + txtDims.X + 20
 
-	if rightmostPointX > float32(rl.GetScreenWidth()-10) {
+	if rightmostPointX > right {
 		distX = -50 - txtDims.X - 20
-	} else if rightmostPointX+distX > float32(rl.GetScreenWidth()-10) {
-		distX = float32(rl.GetScreenWidth()-10) - (mousePosition.X + txtDims.X + 20)
+	} else if rightmostPointX+distX > right {
+		distX = right - (mousePosition.X + txtDims.X + 20)
 	}
 
-	offsetX := rl.Clamp(mousePosition.X+distX, 10, float32(rl.GetScreenWidth())-10-txtDims.X-20)
-	offsetY := rl.Clamp(mousePosition.Y, 10, float32(rl.GetScreenHeight())-10-txtDims.Y-20)
+	offsetX := rl.Clamp(mousePosition.X+distX, screen.X+10, right-txtDims.X-20)
+	offsetY := rl.Clamp(mousePosition.Y, screen.Y+10, screen.Y+screen.Height-10-txtDims.Y-20)
 
 	savedBackgroundColor := gui.GetStyle(gui.DEFAULT, gui.BACKGROUND_COLOR)
 	gui.SetStyle(gui.DEFAULT, gui.BACKGROUND_COLOR, 0xDDDDDDDD)
-	gui.Panel(rl.NewRectangle(offsetX, offsetY, txtDims.X+20, txtDims.Y+20), hoveredNode.Title)
-	rl.DrawTextEx(n.font, hoveredNode.Text, rl.NewVector2(offsetX+10, offsetY+24), 32, 0, rl.Black)
+	gui.Panel(rl.NewRectangle(offsetX, offsetY, txtDims.X+20, txtDims.Y+20), title)
+	rl.DrawTextEx(n.font, text, rl.NewVector2(offsetX+10, offsetY+24), 32, 0, rl.Black)
+	if changes != "" {
+		rl.DrawTextEx(n.font, changes, rl.NewVector2(offsetX+10, offsetY+24+textHeight+10), 24, 0, rl.NewColor(45, 51, 107, 255))
+	}
 
 	gui.SetStyle(gui.DEFAULT, gui.BACKGROUND_COLOR, savedBackgroundColor)
 }
@@ -0,0 +1,134 @@
+package systems
+
+import (
+	"context"
+
+	"github.com/mlange-42/ark/ecs"
+)
+
+// placementMinSize is the size on screen of a node, in pixels, from which its placements can be
+// hovered.
+const placementMinSize = 200
+
+// Placements of a node matching the selected placement
+const (
+	noPlacementMatch = iota
+	sameItem
+	samePlacement
+)
+
+func NewPlacementSelector() *PlacementSelector {
+	return &PlacementSelector{}
+}
+
+// PlacementSelector finds the placement under the mouse when zoomed into a node. Clicking it flags
+// the nodes placing the same item, and the ones placing it at the same position.
+type PlacementSelector struct {
+	nodes  *ecs.Map2[Position, Node]
+	all    *ecs.Filter1[Node]
+	shapes []ShapeDefinition
+
+	input      ecs.Resource[Input]
+	camera     ecs.Resource[CameraHandler]
+	selection  ecs.Resource[NodeSelection]
+	placements ecs.Resource[PlacementSelection]
+
+	// Placement whose matches are flagged on the nodes
+	flagged *ShapeTransform
+}
+
+// Close implements System.
+func (s *PlacementSelector) Close() {
+}
+
+// Initialize implements System.
+func (s *PlacementSelector) Initialize(w *ecs.World) {
+	s.nodes = ecs.NewMap2[Position, Node](w)
+	s.all = ecs.NewFilter1[Node](w)
+	shapes := ecs.NewResource[[]ShapeDefinition](w)
+	s.shapes = *shapes.Get()
+
+	s.input = ecs.NewResource[Input](w)
+	s.camera = ecs.NewResource[CameraHandler](w)
+	s.selection = ecs.NewResource[NodeSelection](w)
+	s.placements = ecs.NewResource[PlacementSelection](w)
+}
+
+// Update implements System.
+func (s *PlacementSelector) Update(ctx context.Context, w *ecs.World) {
+	placements := s.placements.Get()
+	s.hover(placements)
+	if placements.HasHovered() && s.input.Get().Mouse.LeftButton.Pressed {
+		_, n := s.nodes.Get(placements.Node)
+		tr := n.ShapeTransforms[placements.Placement]
+		if placements.Selected != nil && placements.Selected.Key() == tr.Key() {
+			placements.Selected = nil
+		} else {
+			placements.Selected = &tr
+		}
+	}
+
+	if placements.Selected != s.flagged {
+		s.flagMatches(placements)
+	}
+}
+
+func (s *PlacementSelector) hover(placements *PlacementSelection) {
+	placements.Node = ecs.Entity{}
+	placements.Placement = -1
+
+	input := s.input.Get()
+	selection := s.selection.Get()
+	if !input.Active || !selection.HasHovered() || !s.nodes.HasAll(selection.Hovered) {
+		return
+	}
+	pos, n := s.nodes.Get(selection.Hovered)
+	if n.scale == 0 || float32(n.SizeX)*s.camera.Get().Camera.Zoom < placementMinSize {
+		return
+	}
+
+	mouse := input.Mouse.InWorld
+	x := (float32(mouse.X-pos.X) - n.midX) / n.scale
+	y := (float32(mouse.Y-pos.Y) - n.midY) / (reverseY * n.scale)
+	placement, shape := PlacementAt(s.shapes, n.ShapeTransforms, x, y)
+	if placement == -1 {
+		return
+	}
+	placements.Node = selection.Hovered
+	placements.Placement = placement
+	placements.Shape = shape
+}
+
+// flagMatches flags the nodes placing the item of the selected placement.
+func (s *PlacementSelector) flagMatches(placements *PlacementSelection) {
+	s.flagged = placements.Selected
+	placements.SameItem = 0
+	placements.SamePosition = 0
+
+	query := s.all.Query()
+	for query.Next() {
+		n := query.Get()
+		n.placementMatch = noPlacementMatch
+		if placements.Selected == nil {
+			continue
+		}
+		for _, tr := range n.ShapeTransforms {
+			if tr.Key() == placements.Selected.Key() {
+				n.placementMatch = samePlacement
+				break
+			}
+			if tr.Id == placements.Selected.Id {
+				n.placementMatch = sameItem
+			}
+		}
+		switch n.placementMatch {
+		case samePlacement:
+			placements.SamePosition++
+			placements.SameItem++
+		case sameItem:
+			placements.SameItem++
+		}
+	}
+}
+
+var _ System = &PlacementSelector{}
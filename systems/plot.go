@@ -0,0 +1,128 @@
+package systems
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// PlotBounds returns the bounding box of the placed shapes, in the coordinates of the plot.
+func PlotBounds(shapes []ShapeDefinition, transforms []ShapeTransform) (minX, minY, maxX, maxY float32) {
+	minX, minY = math.MaxFloat32, math.MaxFloat32
+	maxX, maxY = -math.MaxFloat32, -math.MaxFloat32
+	for _, tr := range transforms {
+		def := shapes[tr.Id]
+		minX = min(minX, tr.X+def.MinX)
+		minY = min(minY, tr.Y+def.MinY)
+		maxX = max(maxX, tr.X+def.MaxX)
+		maxY = max(maxY, tr.Y+def.MaxY)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// PlotScale returns the scale and the screen position of the origin of the plot when it is fitted
+// in the area, then zoomed around the center of the area and moved by pan. The y axis points up, as
+// in the tree.
+func PlotScale(shapes []ShapeDefinition, transforms []ShapeTransform, area rl.Rectangle, zoom float32, pan rl.Vector2) (float32, rl.Vector2) {
+	minX, minY, maxX, maxY := PlotBounds(shapes, transforms)
+	dim := max(maxX-minX, maxY-minY)
+	if dim <= 0 {
+		return 1, rl.NewVector2(area.X, area.Y)
+	}
+	scale := min(area.Width, area.Height) * 0.95 / dim * zoom
+	origin := rl.NewVector2(
+		area.X+area.Width/2-scale*(minX+maxX)/2+pan.X,
+		area.Y+area.Height/2+scale*(minY+maxY)/2+pan.Y)
+	return scale, origin
+}
+
+// DrawPlot draws the shapes of a node in the area, as placed by PlotScale. Shapes out of the area
+// are not clipped.
+func DrawPlot(shapes []ShapeDefinition, transforms []ShapeTransform, area rl.Rectangle, zoom float32, pan rl.Vector2) {
+	if len(transforms) == 0 {
+		return
+	}
+	scale, origin := PlotScale(shapes, transforms, area, zoom, pan)
+	for _, tr := range transforms {
+		for _, s := range shapes[tr.Id].Shapes {
+			if len(s.Points) == 0 {
+				continue
+			}
+			renderShape(s, tr.Highlighted(), origin.X+scale*tr.X, origin.Y-scale*tr.Y, scale, -scale)
+		}
+	}
+}
+
+// PlacementAt returns the index of the placement with a shape containing the point of the plot, and
+// the index of the shape in its item. Placements drawn last are on top. It returns -1, -1 when there
+// is none.
+func PlacementAt(shapes []ShapeDefinition, transforms []ShapeTransform, x, y float32) (int, int) {
+	for i := len(transforms) - 1; i >= 0; i-- {
+		tr := transforms[i]
+		def := shapes[tr.Id]
+		px, py := float64(x-tr.X), float64(y-tr.Y)
+		if px < float64(def.MinX) || px > float64(def.MaxX) || py < float64(def.MinY) || py > float64(def.MaxY) {
+			continue
+		}
+		for j, s := range def.Shapes {
+			if s.Open || !insidePolygon(s.Points, px, py) {
+				continue
+			}
+			inHole := false
+			for _, hole := range s.Holes {
+				inHole = inHole || insidePolygon(hole, px, py)
+			}
+			if !inHole {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// insidePolygon returns whether the point is inside the polygon, with the even-odd rule.
+func insidePolygon(points []Position, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		a, b := points[i], points[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// PlacementText describes a placement and the shape of its item under the mouse.
+func PlacementText(shapes []ShapeDefinition, transforms []ShapeTransform, placement, shape int) string {
+	tr := transforms[placement]
+	color := shapes[tr.Id].Shapes[shape].Color
+	if color == "" {
+		color = "none"
+	}
+	return fmt.Sprintf("placement %d\nitem %d, shape %d\nposition (%g, %g)\ncolor %s",
+		placement, tr.Id, shape, tr.X, tr.Y, color)
+}
+
+// outlinePlacement draws the borders of the shapes of a placement, thicker than usual.
+func outlinePlacement(def ShapeDefinition, offsetX, offsetY, scaleX, scaleY float32, thickness float32, color rl.Color) {
+	for _, s := range def.Shapes {
+		for i := range s.Points {
+			a, b := s.Points[i], s.Points[(i+1)%len(s.Points)]
+			if s.Open && i == len(s.Points)-1 {
+				break
+			}
+			rl.DrawLineEx(
+				rl.NewVector2(offsetX+scaleX*float32(a.X), offsetY+scaleY*float32(a.Y)),
+				rl.NewVector2(offsetX+scaleX*float32(b.X), offsetY+scaleY*float32(b.Y)),
+				thickness, color)
+		}
+	}
+}
+
+// DrawPlotOutline outlines a placement of the plot drawn by DrawPlot.
+func DrawPlotOutline(shapes []ShapeDefinition, transforms []ShapeTransform, placement int, area rl.Rectangle, zoom float32, pan rl.Vector2, color rl.Color) {
+	scale, origin := PlotScale(shapes, transforms, area, zoom, pan)
+	tr := transforms[placement]
+	outlinePlacement(shapes[tr.Id], origin.X+scale*tr.X, origin.Y-scale*tr.Y, scale, -scale, 3, color)
+}
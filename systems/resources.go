@@ -9,6 +9,8 @@ import (
 
 type Mappings struct {
 	nodeLookup map[uint64]ecs.Entity
+	// Node ids by entity, the reverse of nodeLookup
+	nodeIds    map[ecs.Entity]uint64
 	edgeLookup map[[2]uint64]ecs.Entity
 }
 
@@ -88,6 +90,30 @@ func (s NodeSelection) HasSelected() bool {
 	return !s.Selected.IsZero()
 }
 
+// BestSolution is the node of the best solution of a branch and bound, the path from the root to it
+// being highlighted. Node is zero when there is no solution.
+type BestSolution struct {
+	Node ecs.Entity
+}
+
+// PlacementSelection is the placement under the mouse in a zoomed node, and the placement whose item
+// is looked for in the tree.
+type PlacementSelection struct {
+	Node      ecs.Entity
+	Placement int
+	// Index of the shape under the mouse in the item
+	Shape int
+
+	Selected *ShapeTransform
+	// Number of nodes placing the selected item, and placing it at the same position
+	SameItem     int
+	SamePosition int
+}
+
+func (s PlacementSelection) HasHovered() bool {
+	return !s.Node.IsZero()
+}
+
 type NavType uint
 
 const (
@@ -195,3 +221,20 @@ func (boxes *SubTreeBoundingBoxes) NodeMoved(node ecs.Entity) {
 		}
 	}
 }
+
+// Screen is the area of the window where the graph is drawn. The zero value is the whole window.
+type Screen struct {
+	Area rl.Rectangle
+}
+
+func (s Screen) Rect() rl.Rectangle {
+	if !s.Clipped() {
+		return rl.NewRectangle(0, 0, float32(rl.GetScreenWidth()), float32(rl.GetScreenHeight()))
+	}
+	return s.Area
+}
+
+// Clipped tells whether the graph is drawn on a part of the window only.
+func (s Screen) Clipped() bool {
+	return s.Area.Width > 0 && s.Area.Height > 0
+}
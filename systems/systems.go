@@ -3,8 +3,10 @@ package systems
 import (
 	"context"
 	"fmt"
+	"image/color"
 	"time"
 
+	rl "github.com/gen2brain/raylib-go/raylib"
 	"github.com/mlange-42/ark/ecs"
 	"github.com/phuslu/log"
 )
@@ -21,10 +23,15 @@ type Systems struct {
 	boundaries   ecs.Resource[Boundaries]
 	camera       ecs.Resource[CameraHandler]
 	grid         ecs.Resource[Grid]
+	screen       ecs.Resource[Screen]
+	placements   ecs.Resource[PlacementSelection]
+
+	boundingBoxes ecs.Resource[SubTreeBoundingBoxes]
 
 	targetBuilder *ecs.Map1[Target2]
 
 	positions       *ecs.Map1[Position]
+	nodeComponents  *ecs.Map1[Node]
 	edges           *ecs.Filter1[Edge]
 	nodes           *ecs.Filter1[Node]
 	selected        ecs.Resource[NodeSelection]
@@ -51,12 +58,19 @@ func (s *Systems) Initialize(w *ecs.World) {
 	s.boundaries.Add(&Boundaries{})
 	s.targetBuilder = ecs.NewMap1[Target2](w)
 	s.positions = ecs.NewMap1[Position](w)
+	s.nodeComponents = ecs.NewMap1[Node](w)
 	s.edges = ecs.NewFilter1[Edge](w)
 	s.nodes = ecs.NewFilter1[Node](w)
 	s.selected = ecs.NewResource[NodeSelection](w)
 	s.visibleElements = ecs.NewMap1[VisibleElement](w)
 	s.hiddenNodes = ecs.NewFilter1[Node](w).Without(ecs.C[VisibleElement]())
 	s.hiddenEdges = ecs.NewFilter1[Edge](w).Without(ecs.C[VisibleElement]())
+	s.camera = ecs.NewResource[CameraHandler](w)
+	s.screen = ecs.NewResource[Screen](w)
+	s.screen.Add(&Screen{})
+	s.placements = ecs.NewResource[PlacementSelection](w)
+	s.placements.Add(&PlacementSelection{Placement: -1})
+	s.boundingBoxes = ecs.NewResource[SubTreeBoundingBoxes](w)
 	s.grid = ecs.NewResource[Grid](w)
 	s.grid.Add(&Grid{grid: make(map[GridPos][]ecs.Entity)})
 
@@ -89,6 +103,11 @@ func (s Systems) Update(w *ecs.World) {
 	if s.debugMode {
 		board = s.debugBoard.Get()
 	}
+	screen := s.screen.Get()
+	if screen.Clipped() {
+		rl.BeginScissorMode(int32(screen.Area.X), int32(screen.Area.Y), int32(screen.Area.Width), int32(screen.Area.Height))
+		defer rl.EndScissorMode()
+	}
 	for _, sys := range s.systems {
 		start := time.Now()
 		sys.Update(ctx, w)
@@ -98,6 +117,26 @@ func (s Systems) Update(w *ecs.World) {
 	s.debug.Update(ctx, w)
 }
 
+// SetScreen restricts the drawing to an area of the window. An empty area is the whole window.
+func (s Systems) SetScreen(area rl.Rectangle) {
+	s.screen.Get().Area = area
+}
+
+// Camera returns the camera of the view.
+func (s Systems) Camera() rl.Camera2D {
+	return *s.camera.Get().Camera
+}
+
+// SetCamera replaces the camera, stopping its moves towards a node.
+func (s Systems) SetCamera(camera rl.Camera2D) {
+	*s.camera.Get().Camera = camera
+	for _, sys := range s.systems {
+		if v, ok := sys.(*Viewport); ok {
+			v.Stop()
+		}
+	}
+}
+
 func (s Systems) Close() {
 	for _, sys := range s.systems {
 		sys.Close()
@@ -105,6 +144,17 @@ func (s Systems) Close() {
 	s.debug.Close()
 }
 
+// TextureMemory returns an estimate of the GPU memory used by the textures, in bytes.
+func (s Systems) TextureMemory() int64 {
+	total := int64(0)
+	for _, sys := range s.systems {
+		if d, ok := sys.(*DrawNodes); ok {
+			total += d.textureMemory()
+		}
+	}
+	return total
+}
+
 type System interface {
 	Initialize(w *ecs.World)
 	Update(ctx context.Context, w *ecs.World)
@@ -149,6 +199,29 @@ func (s *Systems) Hide(w *ecs.World, nodeIds []uint64) {
 	}
 }
 
+// Show makes the nodes visible again, with their edges to visible nodes.
+func (s *Systems) Show(w *ecs.World, nodeIds []uint64) {
+	for _, id := range nodeIds {
+		e, ok := s.mappings.Get().nodeLookup[id]
+		if ok && s.visibleElements.Get(e) == nil {
+			s.visibleElements.Add(e, &VisibleElement{})
+		}
+	}
+
+	toShow := make([]ecs.Entity, 0)
+	query := s.edges.Query()
+	for query.Next() {
+		e := query.Get()
+		if s.visibleElements.Get(query.Entity()) == nil && s.visibleElements.Get(e.From) != nil && s.visibleElements.Get(e.To) != nil {
+			toShow = append(toShow, query.Entity())
+		}
+	}
+	// components cannot be added while querying
+	for _, e := range toShow {
+		s.visibleElements.Add(e, &VisibleElement{})
+	}
+}
+
 func (s *Systems) Delete(w *ecs.World, nodeId uint64) {
 	nodeEntity := s.mappings.Get().nodeLookup[nodeId]
 	s.visibleElements.Remove(nodeEntity)
@@ -196,6 +269,117 @@ func (s Systems) GoToNode(nodeId uint64) {
 	}
 }
 
+// CenterOn moves the camera to a visible node, without selecting it.
+func (s Systems) CenterOn(nodeId uint64) {
+	e, ok := s.mappings.Get().nodeLookup[nodeId]
+	if !ok || s.visibleElements.Get(e) == nil {
+		return
+	}
+	for _, s := range s.systems {
+		if sys, ok := s.(*Viewport); ok {
+			sys.MoveTo(e)
+		}
+	}
+}
+
+// SelectNode selects a visible node without moving the camera. It returns false when the node is
+// not visible.
+func (s Systems) SelectNode(nodeId uint64) bool {
+	e, ok := s.mappings.Get().nodeLookup[nodeId]
+	if !ok || s.visibleElements.Get(e) == nil {
+		return false
+	}
+	s.selected.Get().Selected = e
+	return true
+}
+
+// HoveredNode returns the id of the node under the mouse, if any.
+func (s Systems) HoveredNode() (uint64, bool) {
+	return s.nodeId(s.selected.Get().Hovered)
+}
+
+// SelectedNode returns the id of the selected node, if any.
+func (s Systems) SelectedNode() (uint64, bool) {
+	return s.nodeId(s.selected.Get().Selected)
+}
+
+func (s Systems) nodeId(e ecs.Entity) (uint64, bool) {
+	if e.IsZero() {
+		return 0, false
+	}
+	id, ok := s.mappings.Get().nodeIds[e]
+	return id, ok
+}
+
+// SetNodeColors sets the background of the nodes. Nodes missing from colors get the default
+// background.
+func (s Systems) SetNodeColors(colors map[uint64]color.RGBA) {
+	for id, e := range s.mappings.Get().nodeLookup {
+		c, ok := colors[id]
+		if !ok {
+			c = Palette.Background
+		}
+		s.nodeComponents.Get(e).color = c
+	}
+}
+
+// ExportView draws the graph as seen through the camera, without the UI, in an image scale times
+// larger than the screen area. Nodes are drawn at full quality, whatever the time it takes.
+func (s Systems) ExportView(w *ecs.World, scale float32) *rl.Image {
+	area := s.screen.Get().Rect()
+
+	// Same visible world, with more pixels
+	camera := s.camera.Get().Camera
+	saved := *camera
+	camera.Zoom *= scale
+	camera.Offset = rl.Vector2Scale(rl.Vector2Subtract(camera.Offset, rl.NewVector2(area.X, area.Y)), scale)
+	defer func() { *camera = saved }()
+
+	return s.drawOffscreen(w, int32(scale*area.Width), int32(scale*area.Height))
+}
+
+// RenderFrame draws the graph as seen through the camera in an image of the given size, whatever
+// the size of the window, as a frame of an animation.
+func (s Systems) RenderFrame(w *ecs.World, camera rl.Camera2D, width, height int32) *rl.Image {
+	current := s.camera.Get().Camera
+	saved := *current
+	*current = camera
+	defer func() { *current = saved }()
+
+	visible := s.visibleWorld.Get()
+	savedVisible := *visible
+	topLeft := rl.GetScreenToWorld2D(rl.NewVector2(0, 0), camera)
+	botRight := rl.GetScreenToWorld2D(rl.NewVector2(float32(width), float32(height)), camera)
+	*visible = VisibleWorld{X: float64(topLeft.X), Y: float64(topLeft.Y), MaxX: float64(botRight.X), MaxY: float64(botRight.Y)}
+	defer func() { *visible = savedVisible }()
+
+	return s.drawOffscreen(w, width, height)
+}
+
+// drawOffscreen draws the edges and the nodes through the camera in an image.
+func (s Systems) drawOffscreen(w *ecs.World, width, height int32) *rl.Image {
+	target := rl.LoadRenderTexture(width, height)
+	defer rl.UnloadRenderTexture(target)
+
+	rl.BeginTextureMode(target)
+	rl.ClearBackground(rl.White)
+	for _, sys := range s.systems {
+		switch sys := sys.(type) {
+		case *DrawEdges:
+			sys.Update(context.Background(), w)
+		case *DrawNodes:
+			sys.exporting = true
+			sys.Update(context.Background(), w)
+			sys.exporting = false
+		}
+	}
+	rl.EndTextureMode()
+
+	image := rl.LoadImageFromTexture(target.Texture)
+	rl.ImageFlipVertical(image) // textures are upside down
+	return image
+}
+
 func (s Systems) CaptureInput() {
 	s.input.Get().Active = false
 }
@@ -204,7 +388,9 @@ func (s Systems) ReleaseInput() {
 	s.input.Get().Active = true
 }
 
-func (s Systems) SamePositions(nodeId uint64, oldSystems Systems) {
+// SamePositions puts the node where it is in the old systems, moved by offset, and animates it to
+// its position in these systems.
+func (s Systems) SamePositions(nodeId uint64, oldSystems Systems, offset rl.Vector2) {
 	e, ok := s.mappings.Get().nodeLookup[nodeId]
 	if !ok {
 		return
@@ -215,13 +401,92 @@ func (s Systems) SamePositions(nodeId uint64, oldSystems Systems) {
 		return
 	}
 
-	pos := oldSystems.positions.Get(oldE)
+	old := oldSystems.positions.Get(oldE)
+	p := s.positions.Get(e)
+	newX, newY := p.X, p.Y
+
+	startX := old.X + float64(offset.X)
+	startY := old.Y + float64(offset.Y)
+	s.grid.Get().MoveEntity(e, GridCoords(int(p.X), int(p.Y)), GridCoords(int(startX), int(startY)))
+	p.X = startX
+	p.Y = startY
+	s.boundingBoxes.Get().NodeMoved(e)
+
+	s.MoveNode(nil, nodeId, int(newX), int(newY))
+}
 
+// NodePosition returns the position of the node, as it is drawn.
+func (s Systems) NodePosition(nodeId uint64) (rl.Vector2, bool) {
+	e, ok := s.mappings.Get().nodeLookup[nodeId]
+	if !ok {
+		return rl.Vector2{}, false
+	}
 	p := s.positions.Get(e)
-	p.X = pos.X
-	p.Y = pos.Y
+	return rl.NewVector2(float32(p.X), float32(p.Y)), true
+}
 
-	s.MoveNode(nil, nodeId, int(pos.X), int(pos.Y))
+// NodeCenter returns the center of the node, as it is drawn.
+func (s Systems) NodeCenter(nodeId uint64) (rl.Vector2, bool) {
+	e, ok := s.mappings.Get().nodeLookup[nodeId]
+	if !ok {
+		return rl.Vector2{}, false
+	}
+	p, n := s.positions.Get(e), s.nodeComponents.Get(e)
+	return rl.NewVector2(float32(p.X+n.SizeX/2), float32(p.Y+n.SizeY/2)), true
+}
+
+// SetBookmarked marks the nodes with a bookmark.
+func (s Systems) SetBookmarked(bookmarked map[uint64]bool) {
+	for id, e := range s.mappings.Get().nodeLookup {
+		s.nodeComponents.Get(e).bookmarked = bookmarked[id]
+	}
+}
+
+// SetInvalid marks the nodes whose placements overlap or exceed the container.
+func (s Systems) SetInvalid(invalid map[uint64]bool) {
+	for id, e := range s.mappings.Get().nodeLookup {
+		s.nodeComponents.Get(e).invalid = invalid[id]
+	}
+}
+
+// SetDuplicates frames the nodes with a duplicated state.
+func (s Systems) SetDuplicates(duplicates map[uint64]bool) {
+	for id, e := range s.mappings.Get().nodeLookup {
+		s.nodeComponents.Get(e).duplicate = duplicates[id]
+	}
+}
+
+// SetLatest marks the node revealed last by the replay, if any.
+func (s Systems) SetLatest(nodeId uint64, ok bool) {
+	for id, e := range s.mappings.Get().nodeLookup {
+		s.nodeComponents.Get(e).latest = ok && id == nodeId
+	}
+}
+
+// SelectPlacement flags the nodes placing the item of the placement, nil to clear them. Selecting
+// the selected placement again clears it.
+func (s Systems) SelectPlacement(placement *ShapeTransform) {
+	placements := s.placements.Get()
+	if placement != nil && placements.Selected != nil && placements.Selected.Key() == placement.Key() {
+		placement = nil
+	}
+	placements.Selected = placement
+}
+
+// SelectedPlacement returns the placement whose item is looked for in the tree, if any.
+func (s Systems) SelectedPlacement() (ShapeTransform, bool) {
+	placements := s.placements.Get()
+	if placements.Selected == nil {
+		return ShapeTransform{}, false
+	}
+	return *placements.Selected, true
+}
+
+// SetCollapsed marks the nodes whose subtree is collapsed.
+func (s Systems) SetCollapsed(collapsed map[uint64]bool) {
+	for id, e := range s.mappings.Get().nodeLookup {
+		s.nodeComponents.Get(e).collapsed = collapsed[id]
+	}
 }
 
 func (s Systems) SetNodePos(w *ecs.World, nodeId uint64, newX, newY int) {
@@ -0,0 +1,285 @@
+package systems
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/osuushi/triangulate"
+)
+
+// overlapTolerance is the fraction of the area of a placement that can overlap another placement or
+// exceed the container, so that touching items are not reported because of rounding errors.
+const overlapTolerance = 1e-4
+
+type ViolationKind int
+
+const (
+	// Overlap is two placements sharing some area
+	Overlap ViolationKind = iota
+	// OutOfBounds is a placement exceeding the containers
+	OutOfBounds
+)
+
+// Violation is a placement of a node overlapping another one, or out of the containers.
+type Violation struct {
+	Kind ViolationKind
+	// Index of the placements in the node, B being -1 for OutOfBounds
+	A, B int
+	// Area of the overlap, or area out of the containers
+	Area float64
+}
+
+func (v Violation) String() string {
+	if v.Kind == OutOfBounds {
+		return fmt.Sprintf("placement %d is out of the container (area %.4g)", v.A, v.Area)
+	}
+	return fmt.Sprintf("placements %d and %d overlap (area %.4g)", v.A, v.B, v.Area)
+}
+
+type point struct {
+	x, y float64
+}
+
+type triangle [3]point
+
+// region is a closed shape of a placement, made of triangles, and of the triangles of its holes.
+type region struct {
+	outer []triangle
+	holes [][]triangle
+	area  float64
+}
+
+// placementRegions is the area covered by a placement.
+type placementRegions struct {
+	regions                []region
+	minX, minY, maxX, maxY float64
+	area                   float64
+}
+
+// ValidatePlacements returns the placements of a node overlapping each other, or exceeding the
+// containers. Containers are the items flagged as such, placed in the node or at the origin when
+// they are not placed. Open shapes are ignored.
+func ValidatePlacements(shapes []ShapeDefinition, transforms []ShapeTransform) []Violation {
+	cache := make(map[int][]region)
+	placed := func(tr ShapeTransform) placementRegions {
+		regions, ok := cache[tr.Id]
+		if !ok {
+			regions = itemRegions(shapes[tr.Id])
+			cache[tr.Id] = regions
+		}
+		def := shapes[tr.Id]
+		p := placementRegions{
+			regions: make([]region, 0, len(regions)),
+			minX:    float64(tr.X + def.MinX), minY: float64(tr.Y + def.MinY),
+			maxX: float64(tr.X + def.MaxX), maxY: float64(tr.Y + def.MaxY),
+		}
+		for _, r := range regions {
+			p.regions = append(p.regions, r.translated(float64(tr.X), float64(tr.Y)))
+			p.area += r.area
+		}
+		return p
+	}
+
+	items := make([]int, 0, len(transforms))
+	containers := make([]placementRegions, 0)
+	placedContainers := make(map[int]bool)
+	for i, tr := range transforms {
+		if shapes[tr.Id].Container {
+			containers = append(containers, placed(tr))
+			placedContainers[tr.Id] = true
+		} else {
+			items = append(items, i)
+		}
+	}
+	for id, def := range shapes {
+		if def.Container && !placedContainers[id] {
+			containers = append(containers, placed(ShapeTransform{Id: id}))
+		}
+	}
+
+	regions := make([]placementRegions, len(items))
+	for k, i := range items {
+		regions[k] = placed(transforms[i])
+	}
+
+	violations := make([]Violation, 0)
+	for a := range items {
+		for b := a + 1; b < len(items); b++ {
+			if !regions[a].boundsOverlap(regions[b]) {
+				continue
+			}
+			area := intersectionArea(regions[a], regions[b])
+			if area > overlapTolerance*min(regions[a].area, regions[b].area) {
+				violations = append(violations, Violation{Kind: Overlap, A: items[a], B: items[b], Area: area})
+			}
+		}
+	}
+
+	if len(containers) == 0 {
+		return violations
+	}
+	for a := range items {
+		inside := 0.0
+		for _, c := range containers {
+			if regions[a].boundsOverlap(c) {
+				inside += intersectionArea(regions[a], c)
+			}
+		}
+		if out := regions[a].area - inside; out > overlapTolerance*regions[a].area {
+			violations = append(violations, Violation{Kind: OutOfBounds, A: items[a], B: -1, Area: out})
+		}
+	}
+	return violations
+}
+
+// itemRegions triangulates the closed shapes of an item. The triangles computed for drawing are
+// reused, without modifying the shapes.
+func itemRegions(def ShapeDefinition) []region {
+	regions := make([]region, 0, len(def.Shapes))
+	for _, s := range def.Shapes {
+		if s.Open {
+			continue
+		}
+		r := region{outer: shapeTriangles(s)}
+		r.area = trianglesArea(r.outer)
+		for _, hole := range s.Holes {
+			triangles := shapeTriangles(DrawableShape{Points: hole})
+			r.holes = append(r.holes, triangles)
+			r.area -= trianglesArea(triangles)
+		}
+		regions = append(regions, r)
+	}
+	return regions
+}
+
+func shapeTriangles(s DrawableShape) []triangle {
+	if s.Triangles == nil {
+		if err := s.ComputeTriangles(); err != nil {
+			return nil
+		}
+	}
+	triangles := make([]triangle, 0, len(s.Triangles))
+	for _, t := range s.Triangles {
+		triangles = append(triangles, counterClockwiseTriangle(t))
+	}
+	return triangles
+}
+
+func counterClockwiseTriangle(t *triangulate.Triangle) triangle {
+	tr := triangle{{t.A.X, t.A.Y}, {t.B.X, t.B.Y}, {t.C.X, t.C.Y}}
+	if polygonArea(tr[:]) < 0 {
+		tr[1], tr[2] = tr[2], tr[1]
+	}
+	return tr
+}
+
+func (r region) translated(dx, dy float64) region {
+	move := func(triangles []triangle) []triangle {
+		moved := make([]triangle, len(triangles))
+		for i, t := range triangles {
+			for k := range t {
+				moved[i][k] = point{t[k].x + dx, t[k].y + dy}
+			}
+		}
+		return moved
+	}
+	res := region{outer: move(r.outer), area: r.area}
+	for _, h := range r.holes {
+		res.holes = append(res.holes, move(h))
+	}
+	return res
+}
+
+func (p placementRegions) boundsOverlap(o placementRegions) bool {
+	return p.minX < o.maxX && o.minX < p.maxX && p.minY < o.maxY && o.minY < p.maxY
+}
+
+// intersectionArea returns the area shared by two placements. Holes being inside their shape, the
+// area shared by two shapes with holes is, by inclusion-exclusion:
+// |A∩B| - |holes(A)∩B| - |A∩holes(B)| + |holes(A)∩holes(B)|.
+func intersectionArea(a, b placementRegions) float64 {
+	area := 0.0
+	for _, ra := range a.regions {
+		for _, rb := range b.regions {
+			area += trianglesIntersection(ra.outer, rb.outer)
+			for _, h := range ra.holes {
+				area -= trianglesIntersection(h, rb.outer)
+			}
+			for _, h := range rb.holes {
+				area -= trianglesIntersection(ra.outer, h)
+			}
+			for _, ha := range ra.holes {
+				for _, hb := range rb.holes {
+					area += trianglesIntersection(ha, hb)
+				}
+			}
+		}
+	}
+	return max(area, 0)
+}
+
+func trianglesIntersection(a, b []triangle) float64 {
+	area := 0.0
+	for _, ta := range a {
+		for _, tb := range b {
+			area += math.Abs(polygonArea(clipConvex(ta[:], tb)))
+		}
+	}
+	return area
+}
+
+func trianglesArea(triangles []triangle) float64 {
+	area := 0.0
+	for _, t := range triangles {
+		area += math.Abs(polygonArea(t[:]))
+	}
+	return area
+}
+
+// polygonArea returns the signed area of the polygon, positive when counter clockwise (shoelace
+// formula).
+func polygonArea(points []point) float64 {
+	area := 0.0
+	for i := range points {
+		a, b := points[i], points[(i+1)%len(points)]
+		area += a.x*b.y - b.x*a.y
+	}
+	return area / 2
+}
+
+// clipConvex returns the part of the convex polygon inside the counter clockwise triangle
+// (Sutherland-Hodgman).
+func clipConvex(subject []point, clip triangle) []point {
+	output := subject
+	for i := range clip {
+		a, b := clip[i], clip[(i+1)%len(clip)]
+		side := func(p point) float64 {
+			return (b.x-a.x)*(p.y-a.y) - (b.y-a.y)*(p.x-a.x)
+		}
+		input := output
+		output = make([]point, 0, len(input)+1)
+		for j := range input {
+			cur, prev := input[j], input[(j+len(input)-1)%len(input)]
+			sc, sp := side(cur), side(prev)
+			if sc >= 0 {
+				if sp < 0 {
+					output = append(output, intersection(prev, cur, sp, sc))
+				}
+				output = append(output, cur)
+			} else if sp >= 0 {
+				output = append(output, intersection(prev, cur, sp, sc))
+			}
+		}
+		if len(output) < 3 {
+			return nil
+		}
+	}
+	return output
+}
+
+// intersection returns the point of the segment from p to q on the clipping line, sp and sq being
+// their sides of the line.
+func intersection(p, q point, sp, sq float64) point {
+	t := sp / (sp - sq)
+	return point{p.x + t*(q.x-p.x), p.y + t*(q.y-p.y)}
+}
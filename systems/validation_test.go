@@ -0,0 +1,85 @@
+package systems
+
+import (
+	"slices"
+	"testing"
+)
+
+// rectangle is an item of size w x h with its bottom left corner at the origin.
+func rectangle(w, h float32, container bool) ShapeDefinition {
+	return ShapeDefinition{
+		Shapes: []DrawableShape{{Points: []Position{{0, 0}, {float64(w), 0}, {float64(w), float64(h)}, {0, float64(h)}}}},
+		MinX:   0, MinY: 0, MaxX: w, MaxY: h,
+		Container: container,
+	}
+}
+
+func TestValidatePlacements(t *testing.T) {
+	bin := rectangle(10, 10, true)
+	square := rectangle(4, 4, false)
+	shapes := []ShapeDefinition{bin, square}
+
+	tests := []struct {
+		name       string
+		shapes     []ShapeDefinition
+		placements []ShapeTransform
+		want       []Violation
+	}{
+		{
+			name:       "apart",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 1, X: 0, Y: 0}, {Id: 1, X: 6, Y: 6}},
+		},
+		{
+			name:       "touching but not overlapping",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 1, X: 0, Y: 0}, {Id: 1, X: 4, Y: 0}, {Id: 1, X: 0, Y: 4}},
+		},
+		{
+			name:       "overlap",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 1, X: 0, Y: 0}, {Id: 1, X: 2, Y: 2}},
+			want:       []Violation{{Kind: Overlap, A: 0, B: 1, Area: 4}},
+		},
+		{
+			name:       "same position",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 1, X: 3, Y: 3}, {Id: 1, X: 3, Y: 3}},
+			want:       []Violation{{Kind: Overlap, A: 0, B: 1, Area: 16}},
+		},
+		{
+			name:       "against the side of the bin",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 1, X: 6, Y: 0}},
+		},
+		{
+			name:       "out of the bin",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 1, X: 8, Y: 0}},
+			want:       []Violation{{Kind: OutOfBounds, A: 0, B: -1, Area: 8}},
+		},
+		{
+			name:       "out of a placed bin",
+			shapes:     shapes,
+			placements: []ShapeTransform{{Id: 0, X: 20, Y: 0}, {Id: 1, X: 0, Y: 0}},
+			want:       []Violation{{Kind: OutOfBounds, A: 1, B: -1, Area: 16}},
+		},
+		{
+			name:       "no bin",
+			shapes:     []ShapeDefinition{square},
+			placements: []ShapeTransform{{Id: 0, X: 100, Y: 100}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidatePlacements(tt.shapes, tt.placements)
+			if !slices.EqualFunc(got, tt.want, sameViolation) {
+				t.Errorf("ValidatePlacements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func sameViolation(a, b Violation) bool {
+	return a.Kind == b.Kind && a.A == b.A && a.B == b.B && a.Area > b.Area-1e-6 && a.Area < b.Area+1e-6
+}
@@ -17,6 +17,7 @@ type Viewport struct {
 	input        ecs.Resource[Input]
 	visibleWorld ecs.Resource[VisibleWorld]
 	boundaries   ecs.Resource[Boundaries]
+	screen       ecs.Resource[Screen]
 	navMode      ecs.Resource[NavigationMode]
 
 	selection     ecs.Resource[NodeSelection]
@@ -44,6 +45,7 @@ func (v *Viewport) Initialize(w *ecs.World) {
 	v.camera = ecs.NewResource[CameraHandler](w)
 	v.input = ecs.NewResource[Input](w)
 	v.visibleWorld = ecs.NewResource[VisibleWorld](w)
+	v.screen = ecs.NewResource[Screen](w)
 	cameraHandler := v.camera.Get()
 	v.navMode = ecs.NewResource[NavigationMode](w)
 	v.navMode.Add(&NavigationMode{Nav: FreeNav})
@@ -76,8 +78,8 @@ func (v *Viewport) Initialize(w *ecs.World) {
 		NewTarget1Empty(12))
 }
 
-// Return target, zoom
-func (h *CameraHandler) FocusOn(points ...Position) (rl.Vector2, float32) {
+// Return target, zoom to show the points in the screen area
+func (h *CameraHandler) FocusOn(screen rl.Rectangle, points ...Position) (rl.Vector2, float32) {
 	// move camera for the whole scene
 	if len(points) > 0 {
 		minX := points[0].X
@@ -92,8 +94,8 @@ func (h *CameraHandler) FocusOn(points ...Position) (rl.Vector2, float32) {
 			maxY = max(maxY, p.Y)
 		}
 
-		dx := float32(maxX-minX) / float32(rl.GetScreenWidth()-20)
-		dy := float32(maxY-minY) / float32(rl.GetScreenHeight()-20)
+		dx := float32(maxX-minX) / (screen.Width - 20)
+		dy := float32(maxY-minY) / (screen.Height - 20)
 
 		return rl.NewVector2(float32(minX+maxX)/2, float32(minY+maxY)/2), 1 / max(dx, dy)
 	}
@@ -101,6 +103,18 @@ func (h *CameraHandler) FocusOn(points ...Position) (rl.Vector2, float32) {
 	return h.Camera.Target, h.Camera.Zoom
 }
 
+// Stop ends the moves of the camera towards a node.
+func (v *Viewport) Stop() {
+	_, target := v.move.Get(v.cameraEntity)
+	_, targetOffset := v.move.Get(v.cameraOffsetEntity)
+	_, targetZoom := v.zoom.Get(v.cameraZoomEntity)
+	// A move started in this frame would begin at the next tick otherwise
+	target.SinceTick, targetOffset.SinceTick, targetZoom.SinceTick = -1, -1, -1
+	target.Done = true
+	targetOffset.Done = true
+	targetZoom.Done = true
+}
+
 func (v *Viewport) MoveTo(nodeTarget ecs.Entity) {
 	cameraHandler := v.camera.Get()
 	camera := cameraHandler.Camera
@@ -122,7 +136,8 @@ func (v *Viewport) MoveTo(nodeTarget ecs.Entity) {
 	targetZoom.StartX = camera.Zoom
 	zoom.Value = camera.Zoom
 
-	t, z := cameraHandler.FocusOn(points...)
+	screen := v.screen.Get().Rect()
+	t, z := cameraHandler.FocusOn(screen, points...)
 
 	target.X = float64(t.X)
 	target.Y = float64(t.Y)
@@ -133,8 +148,8 @@ func (v *Viewport) MoveTo(nodeTarget ecs.Entity) {
 	offsetpos.X = targetOffset.StartX
 	offsetpos.Y = targetOffset.StartY
 
-	targetOffset.X = float64(rl.GetScreenWidth()) / 2
-	targetOffset.Y = float64(rl.GetScreenHeight()) / 2
+	targetOffset.X = float64(screen.X + screen.Width/2)
+	targetOffset.Y = float64(screen.Y + screen.Height/2)
 	targetOffset.SinceTick = 0
 
 	if target.X == target.StartX && target.Y == target.StartY {
@@ -219,6 +234,9 @@ func (v *Viewport) Update(ctx context.Context, w *ecs.World) {
 
 	}
 
+	screen := v.screen.Get().Rect()
+	center := rl.NewVector2(screen.X+screen.Width/2, screen.Y+screen.Height/2)
+
 	rootQuery := v.root.Query()
 	if rootQuery.Next() {
 		bb := v.boundingBoxes.Get().boundingBoxes[rootQuery.Entity()]
@@ -228,26 +246,26 @@ func (v *Viewport) Update(ctx context.Context, w *ecs.World) {
 			minWin := rl.GetWorldToScreen2D(rl.NewVector2(float32(bb.X), float32(bb.Y)), *camera)
 			maxWin := rl.GetWorldToScreen2D(rl.NewVector2(float32(bb.X+bb.Width), float32(bb.Y+bb.Height)), *camera)
 
-			if minWin.X > float32(rl.GetScreenWidth())/2 {
-				camera.Offset.X += float32(rl.GetScreenWidth())/2 - minWin.X
+			if minWin.X > center.X {
+				camera.Offset.X += center.X - minWin.X
 			}
 
-			if maxWin.X < float32(rl.GetScreenWidth())/2 {
-				camera.Offset.X += float32(rl.GetScreenWidth())/2 - maxWin.X
+			if maxWin.X < center.X {
+				camera.Offset.X += center.X - maxWin.X
 			}
 
-			if minWin.Y > float32(rl.GetScreenHeight())/2 {
-				camera.Offset.Y += float32(rl.GetScreenHeight())/2 - minWin.Y
+			if minWin.Y > center.Y {
+				camera.Offset.Y += center.Y - minWin.Y
 			}
 
-			if maxWin.Y < float32(rl.GetScreenHeight())/2 {
-				camera.Offset.Y += float32(rl.GetScreenHeight())/2 - maxWin.Y
+			if maxWin.Y < center.Y {
+				camera.Offset.Y += center.Y - maxWin.Y
 			}
 		}
 	}
 
-	topLeft := rl.GetScreenToWorld2D(rl.Vector2Zero(), *camera)
-	botRight := rl.GetScreenToWorld2D(rl.NewVector2(float32(rl.GetScreenWidth()), float32(rl.GetScreenHeight())), *camera)
+	topLeft := rl.GetScreenToWorld2D(rl.NewVector2(screen.X, screen.Y), *camera)
+	botRight := rl.GetScreenToWorld2D(rl.NewVector2(screen.X+screen.Width, screen.Y+screen.Height), *camera)
 
 	visibleWorld := v.visibleWorld.Get()
 	visibleWorld.X = float64(topLeft.X)
@@ -1,12 +1,12 @@
 package main
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
 	gui "github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
-	"github.com/gverger/optimview/systems"
 
 	"github.com/ncruces/zenity"
 	"github.com/phuslu/log"
@@ -30,7 +30,7 @@ func (t *TreeScene) Update() SceneID {
 }
 
 func NewTreeScene(app app, font rl.Font) *TreeScene {
-	return &TreeScene{
+	scene := &TreeScene{
 		Scene: Scene{
 			ID: TreeSceneID,
 		},
@@ -38,6 +38,11 @@ func NewTreeScene(app app, font rl.Font) *TreeScene {
 			font:          font,
 			app:           app,
 			ecosystem:     app.loadTree(font),
+			view:          viewKey{store: app.store, tree: app.currentTree, compareTree: -1},
+			views:         newViewCache(),
+			bookmarks:     newBookmarkStore(),
+			validations:   make(map[*GraphView]*treeValidation),
+			history:       newNavigationHistory(),
 			allNodes:      true,
 			editMode:      false,
 			nodeToFind:    "",
@@ -46,6 +51,8 @@ func NewTreeScene(app app, font rl.Font) *TreeScene {
 			mouseCaptured: false,
 		},
 	}
+	scene.engine.applyValidation()
+	return scene
 }
 
 type treeEngine struct {
@@ -54,6 +61,23 @@ type treeEngine struct {
 
 	ecosystem ecosystem
 	allNodes  bool
+	filter    *nodeFilter
+	// Nodes whose subtree is hidden
+	collapsed map[uint64]bool
+	// Data key coloring the nodes, if any
+	colorBy     string
+	colorByEdit bool
+	// Ancestors of the selected node, labelled with the value of a data key
+	crumbs       breadcrumbs
+	crumbKey     string
+	crumbKeyEdit bool
+
+	// Key of the displayed ecosystem, and the ecosystems of the trees viewed recently
+	view  viewKey
+	views *lruCache[viewKey, cachedView]
+
+	// Nodes selected in the displayed ecosystem
+	history *navigationHistory
 
 	editMode bool
 
@@ -62,6 +86,70 @@ type treeEngine struct {
 
 	uiTexture     rl.RenderTexture2D
 	mouseCaptured bool
+
+	pendingExport    *viewExport
+	pendingAnimation *animationExport
+	// Animation being exported, nil otherwise
+	recording *animationRecording
+
+	showStats bool
+	// Bookmarks of the loaded files
+	bookmarks     *bookmarkStore
+	showBookmarks bool
+	// Inspector shows the data and the plot of the selected node
+	showInspector bool
+	inspector     inspector
+	// Replay reveals the nodes in the order they were explored
+	replay replay
+	// Chart of data keys against the exploration order or a data key
+	showChart     bool
+	chart         *chartData
+	chartKeys     string
+	chartKeysEdit bool
+	chartXKey     string
+	chartXKeyEdit bool
+
+	// Statuses of the nodes of the displayed tree, when it is a branch and bound
+	branchAndBound     *branchAndBoundSummary
+	branchAndBoundTree *GraphView
+	// Validation of the placements of the trees, nil while validating
+	validations    map[*GraphView]*treeValidation
+	showValidation bool
+	// Groups of nodes with the same state, framed around the selected node if asked
+	showDuplicates      bool
+	duplicates          *duplicateIndex
+	duplicateKeys       string
+	duplicateKeysEdit   bool
+	highlightDuplicates bool
+	duplicatesFrame     duplicatesFrame
+	// Statistics of the current tree, computed when first displayed
+	stats        *treeStats
+	statsErr     error
+	depthCursors []int
+
+	// Compare mode shows the current tree merged with another one
+	compareMode     bool
+	compareTree     int32
+	compareEditMode bool
+	compareKey      string
+	compareKeyEdit  bool
+	comparison      *treeComparison
+
+	// Split mode shows another tree next to the current one
+	splitMode     bool
+	splitTree     int32
+	splitEditMode bool
+	splitKey      string
+	splitKeyEdit  bool
+	lockCameras   bool
+	stackedSplit  bool
+	split         *splitView
+
+	// Node of the context menu, nil when the menu is closed
+	menuNode     *uint64
+	menuPosition rl.Vector2
+	// Menu of the top bar that is open, empty when none is
+	toolbarMenu string
 }
 
 func (e *treeEngine) handleEvents() SceneID {
@@ -72,17 +160,20 @@ func (e *treeEngine) handleEvents() SceneID {
 			log.Info().Interface("event", event).Msg("event received")
 			switch event := event.(type) {
 			case MoveNodes:
-				for _, node := range e.app.trees[e.app.currentTree].Tree.Nodes {
+				if event.tree != e.displayedTree().Tree {
+					continue
+				}
+				for _, node := range e.displayedTree().Tree.Nodes {
 					if pos, ok := event.positions[node.Id]; ok {
 						e.ecosystem.sys.MoveNode(&e.ecosystem.world, node.Id, pos.X, pos.Y)
 					}
 				}
 			case SwitchSearchTree:
-				close(e.app.events)
-				e.app = newApp(event.graphs)
-				e.ecosystem.sys.Close()
-				e.ecosystem = e.app.loadTree(e.font)
-				e.allNodes = true
+				e.reload(event.files, event.entries)
+			case OpenSession:
+				e.openSession(event)
+			case Validated:
+				e.validated(event)
 			}
 
 		default:
@@ -98,6 +189,61 @@ func navButton(text string) rl.Vector2 {
 	return size
 }
 
+// toolbarMenuItem is an entry of a menu of the top bar: a toggle when on is set, an action otherwise.
+type toolbarMenuItem struct {
+	text   string
+	on     *bool
+	action func()
+}
+
+// drawMenuButton draws the button of the top bar opening the menu called name, and returns its area.
+func (e *treeEngine) drawMenuButton(offsetX float32, height float32, name string) rl.Rectangle {
+	size := navButton(name)
+	rec := rl.NewRectangle(offsetX, 2, size.X, height)
+	if open := gui.Toggle(rec, name, e.toolbarMenu == name); open != (e.toolbarMenu == name) {
+		e.toolbarMenu = ""
+		if open {
+			e.toolbarMenu = name
+		}
+	}
+	return rec
+}
+
+// drawToolbarMenu draws the items of the menu called name below its button when it is open. An
+// action closes the menu, toggles keep it open; clicking elsewhere closes it. It returns the area of
+// the items, empty when the menu is closed.
+func (e *treeEngine) drawToolbarMenu(name string, buttonRec rl.Rectangle, items []toolbarMenuItem) rl.Rectangle {
+	if e.toolbarMenu != name {
+		return rl.Rectangle{}
+	}
+
+	width := buttonRec.Width
+	for _, item := range items {
+		width = max(width, navButton(item.text).X)
+	}
+	itemHeight := float32(30)
+	menuRec := rl.NewRectangle(buttonRec.X, buttonRec.Y+buttonRec.Height+2, width, itemHeight*float32(len(items)))
+
+	mouse := rl.GetMousePosition()
+	clickedAway := rl.IsMouseButtonPressed(rl.MouseButtonLeft) &&
+		!rl.CheckCollisionPointRec(mouse, menuRec) && !rl.CheckCollisionPointRec(mouse, buttonRec)
+
+	for i, item := range items {
+		itemRec := rl.NewRectangle(menuRec.X, menuRec.Y+float32(i)*itemHeight, width, itemHeight)
+		if item.on != nil {
+			*item.on = gui.Toggle(itemRec, item.text, *item.on)
+		} else if gui.Button(itemRec, item.text) {
+			e.toolbarMenu = ""
+			item.action()
+			return menuRec
+		}
+	}
+	if clickedAway {
+		e.toolbarMenu = ""
+	}
+	return menuRec
+}
+
 // Return true if mouse captured
 func (e *treeEngine) drawUI() {
 	if e.uiTexture.Texture.Width != int32(rl.GetScreenWidth()) || e.uiTexture.Texture.Height != int32(rl.GetScreenHeight()) {
@@ -114,39 +260,23 @@ func (e *treeEngine) drawUI() {
 		rl.NewVector2(navRec.X+navRec.Width, navRec.Y+navRec.Height),
 		1, rl.NewColor(209, 217, 224, 255))
 
-	offsetX := 10.0
-
-	// load file
-	loadFileSize := navButton("Load File")
-	loadFileRec := rl.NewRectangle(float32(offsetX), 2, loadFileSize.X, navRec.Height-4)
-	if gui.Button(loadFileRec, "Load File") {
-		file, err := zenity.SelectFile(
-			zenity.Title("Search Tree Explorer"),
-			zenity.Filename(lastOpenFile),
-			zenity.FileFilters{
-				{Name: "Tree file", Patterns: []string{"*.json", "*.json.gz", "*.tar.gz", "*.tgz"}, CaseFold: true},
-			})
-		if err != nil {
-			log.Info().Err(err).Str("file", file).Msg("importing")
-		} else {
-			log.Info().Str("file", file).Msg("importing...")
-			lastOpenFile = file
-			go importFile(e.app.events, file)
-		}
+	// Drawn before the controls of the top bar, so that their dropdown lists and menus are above it
+	if e.editMode || e.compareEditMode || e.splitEditMode || e.toolbarMenu != "" {
+		gui.Lock()
+	}
+	breadcrumbsRec := e.drawBreadcrumbs()
+	gui.Unlock()
+	panelsY := float32(50)
+	if breadcrumbsRec.Height > 0 {
+		panelsY += breadcrumbsRec.Height
 	}
 
-	offsetX += float64(loadFileRec.Width) + 10
+	offsetX := 10.0
 
-	// Reload
-	reloadButtonSize := navButton("Reload File")
-	reloadButtonRec := rl.NewRectangle(float32(offsetX), 2, reloadButtonSize.X, navRec.Height-4)
-	if gui.Button(reloadButtonRec, "Reload File") {
-		log.Info().Str("file", lastOpenFile).Msg("importing...")
-		go importFile(e.app.events, lastOpenFile)
-	}
-	offsetX += float64(reloadButtonRec.Width) + 10
+	fileRec := e.drawMenuButton(float32(offsetX), navRec.Height-4, "File")
+	offsetX += float64(fileRec.Width) + 10
 
-	if e.editMode {
+	if e.editMode || e.compareEditMode || e.splitEditMode {
 		gui.Lock()
 	}
 
@@ -165,9 +295,7 @@ func (e *treeEngine) drawUI() {
 	if gui.DropdownBox(dropDownRec, strings.Join(e.app.treeNames, ";"), &e.app.currentTree, e.editMode) {
 		if e.editMode {
 			if at != e.app.currentTree {
-				e.ecosystem.sys.Close()
-				e.ecosystem = e.app.loadTree(e.font)
-				e.allNodes = true
+				e.switchTree()
 			}
 		}
 		e.editMode = !e.editMode
@@ -181,32 +309,30 @@ func (e *treeEngine) drawUI() {
 	allChildrenSize := navButton(showAllTxt)
 	allChildrenRec := rl.NewRectangle(float32(offsetX), 2, allChildrenSize.X, navRec.Height-4)
 	if gui.Button(allChildrenRec, showAllTxt) {
-
-		currentTree := e.app.trees[e.app.currentTree]
-		if e.allNodes {
-			tree := systems.SearchTree{
-				Tree:   currentTree.Tree.StripNodesWithoutChildren(),
-				Shapes: currentTree.Shapes,
-			}
-
-			toHide := make([]uint64, 0, len(currentTree.Tree.Nodes))
-			for _, node := range currentTree.Tree.Nodes {
-				if !tree.Tree.HasNode(node) {
-					toHide = append(toHide, node.Id)
-				}
-			}
-
-			e.ecosystem.sys.Hide(&e.ecosystem.world, toHide)
-
-			go computePositionsAsync(e.app.events, tree.Tree)
-		} else {
-			e.ecosystem.sys.ShowAll(&e.ecosystem.world)
-
-			go computePositionsAsync(e.app.events, currentTree.Tree)
-		}
-
 		e.allNodes = !e.allNodes
+		e.updateVisibility()
+	}
+	offsetX += float64(allChildrenRec.Width) + 10
+
+	panelsRec := e.drawMenuButton(float32(offsetX), navRec.Height-4, "Panels")
+	offsetX += float64(panelsRec.Width) + 10
+
+	colorBySize := navButton("color by")
+	gui.Label(rl.NewRectangle(float32(offsetX), 2, colorBySize.X, navRec.Height-4), "color by")
+	offsetX += float64(colorBySize.X)
+	colorByRec := rl.NewRectangle(float32(offsetX), 2, 100, navRec.Height-4)
+	if gui.TextBox(colorByRec, &e.colorBy, 32, e.colorByEdit) {
+		if e.colorByEdit {
+			e.applyColors()
+		}
+		e.colorByEdit = !e.colorByEdit
 	}
+	offsetX += float64(colorByRec.Width) + 10
+
+	compareRec := e.drawCompareControls(float32(offsetX), navRec.Height-4)
+	offsetX += float64(compareRec.Width)
+
+	splitRec := e.drawSplitControls(float32(offsetX), navRec.Height-4)
 
 	rightOffsetX := float32(rl.GetScreenWidth())
 	findButtonSize := float32(36.0)
@@ -242,30 +368,223 @@ func (e *treeEngine) drawUI() {
 	}
 
 	gui.Unlock()
+	// The panels below an open menu must not get its clicks
+	if e.toolbarMenu != "" {
+		gui.Lock()
+	}
+	inspectorPanelRec := e.drawInspector(panelsY - 10)
+	area := e.viewArea()
+	statsPanelRec := e.drawStatsPanel(area.X+area.Width-statsPanelWidth-10, panelsY)
+	duplicatesPanelY := panelsY
+	if statsPanelRec.Height > 0 {
+		duplicatesPanelY = statsPanelRec.Y + statsPanelRec.Height + 10
+	}
+	duplicatesPanelRec := e.drawDuplicatesPanel(area.X+area.Width-duplicatesPanelWidth-10, duplicatesPanelY)
+	e.applyDuplicates()
+	comparePanelRec := e.drawComparePanel(area.X+10, panelsY)
+	bookmarksPanelY := panelsY
+	if comparePanelRec.Height > 0 {
+		bookmarksPanelY = comparePanelRec.Y + comparePanelRec.Height + 10
+	}
+	bookmarksPanelRec := e.drawBookmarksPanel(area.X+10, bookmarksPanelY)
+	validationPanelY := bookmarksPanelY
+	if bookmarksPanelRec.Height > 0 {
+		validationPanelY = bookmarksPanelRec.Y + bookmarksPanelRec.Height + 10
+	}
+	validationPanelRec := e.drawValidationPanel(area.X+10, validationPanelY)
+	replayBarRec := e.drawReplayBar()
+	chartPanelRec := e.drawChartPanel()
+	legendPanelRec := e.drawLegendPanel()
+	gui.Unlock()
+
+	fileMenuRec := e.drawToolbarMenu("File", fileRec, []toolbarMenuItem{
+		{text: "Load File", action: e.askLoadFile},
+		{text: "Reload File", action: e.reloadFiles},
+		{text: "Save Session", action: e.askSaveSession},
+		{text: "Export View", action: e.askViewExport},
+		{text: "Export Animation", action: e.askAnimationExport},
+	})
+	replayActive := e.replay.active
+	panelsMenuRec := e.drawToolbarMenu("Panels", panelsRec, []toolbarMenuItem{
+		{text: "Statistics", on: &e.showStats},
+		{text: "Bookmarks", on: &e.showBookmarks},
+		{text: "Inspector", on: &e.showInspector},
+		{text: "Validation", on: &e.showValidation},
+		{text: "Duplicates", on: &e.showDuplicates},
+		{text: "Replay", on: &replayActive},
+		{text: "Chart", on: &e.showChart},
+	})
+	if replayActive != e.replay.active {
+		if replayActive {
+			e.startReplay()
+		} else {
+			e.stopReplay()
+		}
+	}
+	menuRec := e.drawNodeMenu()
 	rl.DrawFPS(10, int32(rl.GetScreenHeight())-20)
 
 	rl.EndTextureMode()
 
-	e.mouseCaptured = e.findMode ||
+	e.mouseCaptured = e.findMode || e.compareKeyEdit || e.splitKeyEdit || e.colorByEdit || e.crumbKeyEdit ||
+		e.duplicateKeysEdit || e.replay.keyEdit || e.chartKeysEdit || e.chartXKeyEdit ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), breadcrumbsRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), fileRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), fileMenuRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), panelsRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), panelsMenuRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), colorByRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), compareRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), splitRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), comparePanelRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), bookmarksPanelRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), validationPanelRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), replayBarRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), chartPanelRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), legendPanelRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), duplicatesPanelRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), inspectorPanelRec) ||
 		rl.CheckCollisionPointRec(rl.GetMousePosition(), findRec) ||
 		rl.CheckCollisionPointRec(rl.GetMousePosition(), allChildrenRec) ||
-		rl.CheckCollisionPointRec(rl.GetMousePosition(), loadFileRec) ||
-		rl.CheckCollisionPointRec(rl.GetMousePosition(), reloadButtonRec)
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), menuRec) ||
+		rl.CheckCollisionPointRec(rl.GetMousePosition(), statsPanelRec)
 }
 
-func (e *treeEngine) Step() SceneID {
-	e.drawUI()
-
-	if e.mouseCaptured {
-		e.ecosystem.sys.CaptureInput()
+// askLoadFile asks for a tree file or a session to open, loaded in the background.
+func (e *treeEngine) askLoadFile() {
+	file, err := zenity.SelectFile(
+		zenity.Title("Search Tree Explorer"),
+		zenity.Filename(lastOpenFile),
+		append(treeFileFilters, sessionFileFilter))
+	if err != nil {
+		log.Info().Err(err).Str("file", file).Msg("importing")
+	} else if isSessionFile(file) {
+		log.Info().Str("file", file).Msg("opening session...")
+		go importSession(e.app.events, file)
 	} else {
-		e.ecosystem.sys.ReleaseInput()
+		log.Info().Str("file", file).Msg("importing...")
+		lastOpenFile = file
+		go importFiles(e.app.events, file)
+	}
+}
+
+// reloadFiles loads the files of the app again, in the background.
+func (e *treeEngine) reloadFiles() {
+	if len(e.app.files) == 0 || slices.Contains(e.app.files, stdinFilename) {
+		log.Warn().Msg("cannot reload stdin")
+		return
 	}
+	log.Info().Strs("files", e.app.files).Msg("importing...")
+	go importFiles(e.app.events, e.app.files...)
+}
+
+// visibleTree is the current tree, restricted by the filter and the "Nodes with children" toggle.
+func (e *treeEngine) visibleTree() *GraphView {
+	tree := e.displayedTree().Tree
+	if e.filter != nil {
+		tree = e.filter.Apply(tree)
+	}
+	if !e.allNodes {
+		tree = tree.StripNodesWithoutChildren()
+	}
+	return withoutCollapsed(tree, e.collapsed)
+}
+
+// updateVisibility hides the nodes that are not in the visible tree, and moves the others to their
+// new positions.
+func (e *treeEngine) updateVisibility() {
+	visible := e.hideInvisible()
+	go computePositionsAsync(e.app.events, e.displayedTree().Tree, visible)
+}
+
+// hideInvisible hides the nodes that are not in the visible tree, and returns the visible tree.
+func (e *treeEngine) hideInvisible() *GraphView {
+	currentTree := e.displayedTree().Tree
+	visible := e.visibleTree()
+
+	toHide := make([]uint64, 0, len(currentTree.Nodes))
+	for _, node := range currentTree.Nodes {
+		if !visible.HasNode(node) {
+			toHide = append(toHide, node.Id)
+		}
+	}
+	// Nodes not revealed yet by the replay
+	toHide = append(toHide, e.replayHidden()...)
+	e.replay.visible = visible
+
+	e.ecosystem.sys.ShowAll(&e.ecosystem.world)
+	e.ecosystem.sys.Hide(&e.ecosystem.world, toHide)
+	e.ecosystem.sys.SetCollapsed(e.collapsed)
+	return visible
+}
+
+// switchTree replaces the ecosystem by the one of the current tree of the app. The previous
+// ecosystem is kept in the cache, to come back to it as it was.
+func (e *treeEngine) switchTree() {
+	e.stopReplay()
+	e.cacheView()
+	key := e.currentViewKey()
+	if !e.restoreView(key) {
+		e.ecosystem = e.loadEcosystem()
+		e.applyColors()
+		e.history = newNavigationHistory()
+		e.allNodes = true
+		e.collapsed = nil
+		if e.filter != nil {
+			e.updateVisibility()
+		}
+	}
+	e.view = key
+	e.applyBookmarks()
+	e.applyValidation()
+	e.menuNode = nil
+	e.stats = nil
+	e.statsErr = nil
+	e.loadSplit()
+}
+
+func (e *treeEngine) setFilter(filter *nodeFilter) {
+	e.filter = filter
+	e.updateVisibility()
+}
+
+func (e *treeEngine) Step() SceneID {
+	if e.pendingExport != nil {
+		e.exportView(*e.pendingExport)
+		e.pendingExport = nil
+	}
+	if e.pendingAnimation != nil {
+		e.startAnimation(*e.pendingAnimation)
+		e.pendingAnimation = nil
+	}
+	if e.recording != nil {
+		return e.stepRecording()
+	}
+
+	e.drawUI()
+	e.updateReplay()
+	e.handleCollapseKey()
+	e.handleHistoryKeys()
 
 	rl.BeginDrawing()
 	rl.ClearBackground(rl.White)
 
-	e.ecosystem.sys.Update(&e.ecosystem.world)
+	if e.split != nil {
+		e.updateSplit()
+	} else {
+		if e.mouseCaptured {
+			e.ecosystem.sys.CaptureInput()
+		} else {
+			e.ecosystem.sys.ReleaseInput()
+		}
+		screen := rl.Rectangle{}
+		if e.showInspector {
+			screen = e.viewArea()
+		}
+		e.ecosystem.sys.SetScreen(screen)
+		e.ecosystem.sys.Update(&e.ecosystem.world)
+	}
+	e.recordHistory()
 
 	rl.DrawTextureRec(e.uiTexture.Texture, rl.NewRectangle(0, 0, float32(rl.GetScreenWidth()), -float32(rl.GetScreenHeight())), rl.Vector2Zero(), rl.White)
 
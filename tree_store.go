@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/gverger/optimview/systems"
+	"github.com/phuslu/log"
+)
+
+// treeEntry is a tree found in an input file. It is only parsed when load is called.
+type treeEntry struct {
+	name string
+	load func() (systems.SearchTree, error)
+	// File containing the tree, empty for stdin
+	file string
+}
+
+// treeStore gives access to the trees of the loaded files. Trees are parsed when first needed and
+// the most recently used ones are kept in memory.
+type treeStore struct {
+	entries []treeEntry
+
+	mu      sync.Mutex
+	cache   *lruCache[int, systems.SearchTree]
+	pending map[int]chan struct{}
+	// Errors of the trees that cannot be parsed, not parsed again
+	failed map[int]error
+	// Trees displayed in place of the ones that cannot be parsed, the same every time
+	unreadable map[int]systems.SearchTree
+}
+
+func newTreeStore(entries []treeEntry, cacheSize int) *treeStore {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	return &treeStore{
+		entries: entries,
+		cache:   newLRUCache[int, systems.SearchTree](cacheSize),
+		pending: make(map[int]chan struct{}),
+		failed:  make(map[int]error),
+
+		unreadable: make(map[int]systems.SearchTree),
+	}
+}
+
+func (s *treeStore) Len() int {
+	return len(s.entries)
+}
+
+// File returns the file containing the tree at index i, empty for stdin.
+func (s *treeStore) File(i int) string {
+	return s.entries[i].file
+}
+
+func (s *treeStore) Names() []string {
+	names := make([]string, 0, len(s.entries))
+	for _, e := range s.entries {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+// Tree returns the tree at index i, parsing it if it is not in the cache. Concurrent calls for the
+// same tree wait for a single parse. A tree that cannot be parsed keeps returning its error.
+func (s *treeStore) Tree(i int) (systems.SearchTree, error) {
+	for {
+		s.mu.Lock()
+		if tree, ok := s.cache.Get(i); ok {
+			s.mu.Unlock()
+			return tree, nil
+		}
+		if err, ok := s.failed[i]; ok {
+			s.mu.Unlock()
+			return systems.SearchTree{}, err
+		}
+		if done, ok := s.pending[i]; ok {
+			s.mu.Unlock()
+			<-done
+			continue
+		}
+		done := make(chan struct{})
+		s.pending[i] = done
+		s.mu.Unlock()
+
+		log.Info().Str("tree", s.entries[i].name).Msg("parsing tree")
+		tree, err := s.entries[i].load()
+		if err != nil {
+			err = fmt.Errorf("tree %s: %w", s.entries[i].name, err)
+		}
+
+		s.mu.Lock()
+		if err != nil {
+			s.failed[i] = err
+		} else {
+			s.cache.Put(i, tree)
+		}
+		delete(s.pending, i)
+		s.mu.Unlock()
+		close(done)
+
+		return tree, err
+	}
+}
+
+// Prefetch parses the tree at index i in the background, so that selecting it later is instant. An
+// error is only logged, it is reported when the tree is displayed.
+func (s *treeStore) Prefetch(i int) {
+	if i < 0 || i >= len(s.entries) {
+		return
+	}
+
+	s.mu.Lock()
+	_, loading := s.pending[i]
+	_, failed := s.failed[i]
+	cached := s.cache.Has(i)
+	s.mu.Unlock()
+
+	if !loading && !failed && !cached {
+		go func() {
+			if _, err := s.Tree(i); err != nil {
+				log.Warn().Err(err).Msg("cannot prefetch tree")
+			}
+		}()
+	}
+}
+
+// LoadAll parses every tree, decoding the entries in parallel. Trees already in the cache are
+// reused, the others are not added to it to avoid evicting the trees being viewed. It fails with the
+// error of the first tree that cannot be parsed.
+func (s *treeStore) LoadAll() ([]systems.SearchTree, error) {
+	trees := make([]systems.SearchTree, len(s.entries))
+	errs := make([]error, len(s.entries))
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(s.entries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				s.mu.Lock()
+				tree, ok := s.cache.Get(i)
+				s.mu.Unlock()
+				if !ok {
+					var err error
+					if tree, err = s.entries[i].load(); err != nil {
+						errs[i] = fmt.Errorf("tree %s: %w", s.entries[i].name, err)
+					}
+				}
+				trees[i] = tree
+			}
+		}()
+	}
+
+	for i := range s.entries {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return trees, nil
+}
+
+// Displayed returns the tree at index i, or when it cannot be parsed a tree whose single node tells
+// why.
+func (s *treeStore) Displayed(i int) systems.SearchTree {
+	tree, err := s.Tree(i)
+	if err == nil {
+		return tree
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tree, ok := s.unreadable[i]
+	if !ok {
+		log.Error().Err(err).Msg("cannot load tree")
+		tree = unreadableTree(err)
+		s.unreadable[i] = tree
+	}
+	return tree
+}
+
+func unreadableTree(err error) systems.SearchTree {
+	tree := Tree{Nodes: []*TNode{nil}}.ToGraph()
+	tree.Nodes[0].Text = "cannot load the tree:\n" + err.Error()
+	return systems.SearchTree{Tree: tree, Shapes: make([]systems.ShapeDefinition, 0)}
+}
+
+// loadAllTrees parses every tree of the files, sorted by name, for the commands without window.
+func loadAllTrees(files []string) ([]systems.SearchTree, []string, error) {
+	entries := make([]treeEntry, 0)
+	for _, f := range files {
+		fileEntries, err := indexSearchTrees(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	store := newTreeStore(entries, 1)
+	trees, err := store.LoadAll()
+	return trees, store.Names(), err
+}
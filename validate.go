@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	gui "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"github.com/gverger/optimview/systems"
+	"github.com/phuslu/log"
+)
+
+// errInvalidNodes is returned by validate when some placements overlap or exceed the container.
+var errInvalidNodes = errors.New("invalid nodes")
+
+// nodeViolations are the placements of a node overlapping each other or exceeding the container.
+type nodeViolations struct {
+	Node       uint64   `json:"node"`
+	Violations []string `json:"violations"`
+}
+
+type treeValidation struct {
+	Name    string           `json:"tree"`
+	Nodes   int              `json:"nodes"`
+	Invalid []nodeViolations `json:"invalid"`
+}
+
+// validateTree checks the placements of every node of the tree, in the order of the nodes.
+func validateTree(name string, tree systems.SearchTree) treeValidation {
+	validation := treeValidation{Name: name, Nodes: len(tree.Tree.Nodes), Invalid: make([]nodeViolations, 0)}
+	if len(tree.Shapes) == 0 {
+		return validation
+	}
+	for _, n := range tree.Tree.Nodes {
+		violations := systems.ValidatePlacements(tree.Shapes, n.Transform)
+		if len(violations) == 0 {
+			continue
+		}
+		texts := make([]string, 0, len(violations))
+		for _, v := range violations {
+			texts = append(texts, v.String())
+		}
+		validation.Invalid = append(validation.Invalid, nodeViolations{Node: n.Id, Violations: texts})
+	}
+	return validation
+}
+
+func loadTreeValidations(files []string) ([]treeValidation, error) {
+	trees, names, err := loadAllTrees(files)
+	if err != nil {
+		return nil, err
+	}
+	validations := make([]treeValidation, 0, len(names))
+	for i, tree := range trees {
+		validations = append(validations, validateTree(names[i], tree))
+	}
+	return validations, nil
+}
+
+func writeValidations(w io.Writer, validations []treeValidation) error {
+	for _, v := range validations {
+		if _, err := fmt.Fprintf(w, "%s: %d of %d nodes invalid\n", v.Name, len(v.Invalid), v.Nodes); err != nil {
+			return err
+		}
+		for _, n := range v.Invalid {
+			for _, violation := range n.Violations {
+				if _, err := fmt.Fprintf(w, "  node %d: %s\n", n.Node, violation); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateAsync checks the placements of the tree in the background, the nodes of large trees
+// having many placements.
+func validateAsync(events chan<- Event, tree systems.SearchTree) {
+	events <- Validated{tree: tree.Tree, validation: validateTree("", tree)}
+}
+
+// applyValidation shows the warning badges of the displayed tree, validating it in the background
+// the first time it is displayed.
+func (e *treeEngine) applyValidation() {
+	tree := e.displayedTree()
+	validation, ok := e.validations[tree.Tree]
+	if !ok {
+		if len(tree.Shapes) > 0 {
+			e.validations[tree.Tree] = nil
+			go validateAsync(e.app.events, tree)
+		}
+		e.ecosystem.sys.SetInvalid(nil)
+		return
+	}
+	if validation == nil {
+		// still validating
+		e.ecosystem.sys.SetInvalid(nil)
+		return
+	}
+	invalid := make(map[uint64]bool, len(validation.Invalid))
+	for _, n := range validation.Invalid {
+		invalid[n.Node] = true
+	}
+	e.ecosystem.sys.SetInvalid(invalid)
+}
+
+func (e *treeEngine) validated(event Validated) {
+	if _, ok := e.validations[event.tree]; !ok {
+		// the tree was reloaded in the meantime
+		return
+	}
+	e.validations[event.tree] = &event.validation
+	log.Info().Int("invalid nodes", len(event.validation.Invalid)).Msg("placements validated")
+	if event.tree == e.displayedTree().Tree {
+		e.applyValidation()
+	}
+}
+
+const validationPanelWidth = 420
+
+// validationPanelRows is the number of invalid nodes listed, the others being summed up.
+const validationPanelRows = 20
+
+// drawValidationPanel lists the nodes of the displayed tree with overlapping or out of bounds
+// placements at (x, y). Clicking a node goes to it. It returns the area of the panel, empty when
+// hidden.
+func (e *treeEngine) drawValidationPanel(x, y float32) rl.Rectangle {
+	if !e.showValidation {
+		return rl.Rectangle{}
+	}
+	tree := e.displayedTree()
+	validation, ok := e.validations[tree.Tree]
+
+	lines := make([]string, 0)
+	nodes := make([]uint64, 0)
+	switch {
+	case len(tree.Shapes) == 0:
+		lines = append(lines, "no placements in this tree")
+	case !ok || validation == nil:
+		lines = append(lines, "validating...")
+	case len(validation.Invalid) == 0:
+		lines = append(lines, fmt.Sprintf("all %d nodes are valid", validation.Nodes))
+	default:
+		for _, n := range validation.Invalid[:min(len(validation.Invalid), validationPanelRows)] {
+			text := fmt.Sprintf("%d: %s", n.Node, n.Violations[0])
+			if len(n.Violations) > 1 {
+				text += fmt.Sprintf(" (+%d)", len(n.Violations)-1)
+			}
+			lines = append(lines, text)
+			nodes = append(nodes, n.Node)
+		}
+		if more := len(validation.Invalid) - validationPanelRows; more > 0 {
+			lines = append(lines, fmt.Sprintf("... and %d more nodes", more))
+		}
+	}
+
+	lineHeight := float32(20)
+	height := 24 + 10 + lineHeight*float32(len(lines)) + 10
+	panelRec := rl.NewRectangle(x, y, validationPanelWidth, height)
+	title := "Validation"
+	if ok && validation != nil && len(validation.Invalid) > 0 {
+		title = fmt.Sprintf("Validation: %d invalid nodes", len(validation.Invalid))
+	}
+	gui.Panel(panelRec, title)
+
+	x = panelRec.X + 10
+	y = panelRec.Y + 24 + 10
+	mouse := rl.GetMousePosition()
+	for i, line := range lines {
+		color := rl.Black
+		if i >= len(nodes) {
+			color = rl.DarkGray
+		} else if row := rl.NewRectangle(panelRec.X, y, panelRec.Width, lineHeight); rl.CheckCollisionPointRec(mouse, row) {
+			color = rl.NewColor(45, 51, 107, 255)
+			rl.DrawRectangleRec(row, rl.NewColor(169, 181, 223, 120))
+			if rl.IsMouseButtonPressed(rl.MouseButtonLeft) {
+				if !e.ecosystem.sys.HasNode(nodes[i]) {
+					log.Warn().Uint64("node", nodes[i]).Msg("invalid node is not visible")
+				}
+				e.ecosystem.sys.GoToNode(nodes[i])
+			}
+		}
+		rl.DrawTextEx(e.font, truncateText(line, 50), rl.NewVector2(x, y), 16, 0, color)
+		y += lineHeight
+	}
+
+	return panelRec
+}
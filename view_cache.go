@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/phuslu/log"
+)
+
+// viewKey identifies the ecosystem of a tree, or of the comparison of two trees.
+type viewKey struct {
+	store *treeStore
+	tree  int32
+	// Tree compared with the first one, -1 when not comparing
+	compareTree int32
+	matchKey    string
+}
+
+// cachedView is an ecosystem kept alive after switching to another tree, with the state of the
+// engine that goes with it. The camera, the selection and the hidden nodes are in the ecosystem.
+type cachedView struct {
+	ecosystem  ecosystem
+	comparison *treeComparison
+	allNodes   bool
+	filter     *nodeFilter
+	collapsed  map[uint64]bool
+	history    *navigationHistory
+	nodes      int
+}
+
+// Rough memory used by a node in the ECS world, besides its textures
+const nodeMemory = 1024
+
+func newViewCache() *lruCache[viewKey, cachedView] {
+	return newWeightedLRUCache(config.ViewCacheSize, int64(config.ViewCacheMemory)<<20,
+		func(v cachedView) int64 {
+			return v.ecosystem.sys.TextureMemory() + nodeMemory*int64(v.nodes)
+		},
+		func(key viewKey, v cachedView) {
+			log.Debug().Int32("tree", key.tree).Msg("closing cached view")
+			v.ecosystem.sys.Close()
+		})
+}
+
+// currentViewKey is the key of the ecosystem to display for the current tree and compare mode.
+func (e *treeEngine) currentViewKey() viewKey {
+	key := viewKey{store: e.app.store, tree: e.app.currentTree, compareTree: -1}
+	if e.compareMode {
+		key.compareTree = min(e.compareTree, int32(len(e.app.treeNames)-1))
+		key.matchKey = strings.TrimSpace(e.compareKey)
+	}
+	return key
+}
+
+// cacheView keeps the displayed ecosystem in the cache, or closes it when it comes from files that
+// are not loaded anymore.
+func (e *treeEngine) cacheView() {
+	if e.view.store != e.app.store {
+		e.ecosystem.sys.Close()
+		e.views.Clear()
+		return
+	}
+	e.views.Put(e.view, cachedView{
+		ecosystem:  e.ecosystem,
+		comparison: e.comparison,
+		allNodes:   e.allNodes,
+		filter:     e.filter,
+		collapsed:  e.collapsed,
+		history:    e.history,
+		nodes:      len(e.displayedTree().Tree.Nodes),
+	})
+}
+
+// restoreView displays the cached ecosystem of the key, if any.
+func (e *treeEngine) restoreView(key viewKey) bool {
+	view, ok := e.views.Take(key)
+	if !ok {
+		return false
+	}
+	e.ecosystem = view.ecosystem
+	e.comparison = view.comparison
+	e.allNodes = view.allNodes
+	e.collapsed = view.collapsed
+	e.history = view.history
+	e.applyColors()
+	if view.filter != e.filter {
+		e.updateVisibility()
+	}
+	return true
+}